@@ -0,0 +1,107 @@
+package bitvector
+
+import "slices"
+
+// arrowPadding is the recommended alignment and padding of Arrow buffers.
+const arrowPadding = 64
+
+// ArrowBitmap represents Apache Arrow validity bitmap. Bits are LSB-first, set bit i means that slot Offset+i is
+// valid (not null).
+type ArrowBitmap struct {
+	// Buf contains bitmap bytes. Nil buffer means that all slots are valid.
+	Buf []byte
+	// Offset of the first slot in bits.
+	Offset int
+	// Length is the number of slots.
+	Length int
+	// NullCount is the number of null slots, -1 if unknown.
+	NullCount int64
+}
+
+// FromArrow makes vector of bm.Length bits from Arrow validity bitmap.
+//
+// When offset is a multiple of 8 and unused bits of the last byte are clear, the vector borrows bm.Buf without copying
+// (see FromBytes for ownership rules). Otherwise, bits are copied to the new vector.
+func FromArrow(bm ArrowBitmap) (Interface, error) {
+	if bm.Length <= 0 {
+		return nil, ErrZeroSize
+	}
+	size := uint64(bm.Length)
+	if bm.Buf == nil {
+		vec, _ := NewVector(size)
+		vec.(*vector).SetRange(0, size)
+		return vec, nil
+	}
+	lo, hi := bm.Offset/8, (bm.Offset+bm.Length+7)/8
+	if bm.Offset < 0 || len(bm.Buf) < hi {
+		return nil, ErrShortBuffer
+	}
+	buf, shift := bm.Buf[lo:hi], bm.Offset%8
+	tail := uint8(0xff) << ((bm.Offset + bm.Length) % 8)
+	if tail == 0xff {
+		tail = 0
+	}
+	if shift == 0 && buf[len(buf)-1]&tail == 0 {
+		return FromBytes(buf, size)
+	}
+
+	vec := &vector{
+		buf: make([]uint8, size/8+1),
+		c:   size,
+	}
+	for i := 0; i < len(vec.buf) && i < len(buf); i++ {
+		b := buf[i] >> shift
+		if shift > 0 && i+1 < len(buf) {
+			b |= buf[i+1] << (8 - shift)
+		}
+		vec.buf[i] = b
+	}
+	// Clear bits exceeding the length.
+	vec.buf[size/8] &= ^(uint8(0xff) << (size % 8))
+	vec.s = vec.Popcnt()
+	return vec, nil
+}
+
+// ToArrow exports first length bits of the vector to Arrow validity bitmap with zero offset.
+//
+// Buffer is padded with zeros to 64 bytes and reuses memory of dst. Null count is calculated.
+func ToArrow(dst []byte, vec Interface, length int) (ArrowBitmap, error) {
+	if length <= 0 {
+		return ArrowBitmap{}, ErrZeroSize
+	}
+	if uint64(length) > bitLen(vec) {
+		return ArrowBitmap{}, ErrShortBuffer
+	}
+	n := (length + 7) / 8
+	dst = ExportBytes(dst[:0], vec, LSBFirst)[:n]
+	if length%8 != 0 {
+		dst[n-1] &= ^(uint8(0xff) << (length % 8))
+	}
+	padded := (n + arrowPadding - 1) / arrowPadding * arrowPadding
+	dst = slices.Grow(dst, padded-n)
+	dst = dst[:padded]
+	clear(dst[n:])
+	return ArrowBitmap{
+		Buf:       dst,
+		Length:    length,
+		NullCount: int64(length) - int64(popcntBytes(dst[:n])),
+	}, nil
+}
+
+// ArrowNullCount returns number of null slots of Arrow validity bitmap.
+func ArrowNullCount(bm ArrowBitmap) (int64, error) {
+	if bm.Buf == nil {
+		return 0, nil
+	}
+	vec, err := FromArrow(bm)
+	if err != nil {
+		return 0, err
+	}
+	return int64(bm.Length) - int64(vec.Popcnt()), nil
+}
+
+// popcntBytes returns population count of buf using vectorised implementation.
+func popcntBytes(buf []byte) uint64 {
+	vec := vector{buf: buf}
+	return vec.Popcnt()
+}
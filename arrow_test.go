@@ -0,0 +1,62 @@
+package bitvector
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestArrow(t *testing.T) {
+	// Validity of 20 slots: slots 0, 3, 9 and 12-17 are valid.
+	buf := make([]byte, 64)
+	buf[0], buf[1], buf[2] = 0x09, 0xf2, 0x03
+	t.Run("aligned", func(t *testing.T) {
+		vec, err := FromArrow(ArrowBitmap{Buf: buf, Length: 20, NullCount: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := fmt.Sprintf("%v", vec); r != "{0,3,9,12-17}" {
+			t.Errorf("unexpected %s", r)
+		}
+		if &bytesOf(vec)[0] != &buf[0] {
+			t.Error("aligned bitmap must be borrowed")
+		}
+	})
+	t.Run("offset", func(t *testing.T) {
+		vec, err := FromArrow(ArrowBitmap{Buf: buf, Offset: 3, Length: 12})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := fmt.Sprintf("%v", vec); r != "{0,6,9-11}" {
+			t.Errorf("unexpected %s", r)
+		}
+		vec, _ = FromArrow(ArrowBitmap{Buf: buf, Offset: 8, Length: 5})
+		if r := fmt.Sprintf("%v", vec); r != "{1,4}" || &bytesOf(vec)[0] == &buf[1] {
+			t.Errorf("unexpected %s", r)
+		}
+	})
+	t.Run("all valid", func(t *testing.T) {
+		vec, _ := FromArrow(ArrowBitmap{Length: 10})
+		if vec.Popcnt() != 10 {
+			t.Errorf("unexpected %v", vec)
+		}
+	})
+	t.Run("export", func(t *testing.T) {
+		vec, _ := Parse("0,3,9,12-17,25", KindVector)
+		bm, err := ToArrow(nil, vec, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bm.Buf) != 64 || bm.Buf[0] != 0x09 || bm.Buf[1] != 0xf2 || bm.Buf[2] != 0x03 || bm.Buf[3] != 0 {
+			t.Errorf("unexpected buffer %x", bm.Buf[:4])
+		}
+		if bm.NullCount != 11 {
+			t.Errorf("null count mismatch: %d", bm.NullCount)
+		}
+		if nc, _ := ArrowNullCount(ArrowBitmap{Buf: bm.Buf, Offset: 1, Length: 16}); nc != 9 {
+			t.Errorf("null count mismatch: %d", nc)
+		}
+		if _, err = ToArrow(nil, vec, 100); err != ErrShortBuffer {
+			t.Errorf("expected short buffer error, got %v", err)
+		}
+	})
+}
@@ -3,8 +3,8 @@ package bitvector
 import (
 	"encoding/binary"
 	"io"
+	"math"
 	"sort"
-	"unsafe"
 )
 
 type bitmap struct {
@@ -13,21 +13,13 @@ type bitmap struct {
 }
 
 func (b *bitmap) add(x uint32) {
-	const maxSize = 4096
 	n := len(b.buf)
-	if n == 0 {
-		return
-	}
-	if n < maxSize && b.buf[n-1] < x {
+	if n == 0 || b.buf[n-1] < x {
 		b.buf = append(b.buf, x)
 		return
 	}
-	pos := sort.Search(n, func(i int) bool { return b.buf[i] == x })
+	pos := search(b.buf, x)
 	if pos < 0 {
-		if n >= maxSize {
-			// todo add new bitmap
-			return
-		}
 		pos1 := -pos - 1
 		b.buf = append(b.buf, 0)
 		copy(b.buf[pos1+1:], b.buf[pos1:])
@@ -36,7 +28,7 @@ func (b *bitmap) add(x uint32) {
 }
 
 func (b *bitmap) remove(x uint32) bool {
-	i := sort.Search(len(b.buf), func(i int) bool { return b.buf[i] == x })
+	i := search(b.buf, x)
 	if i < 0 {
 		return false
 	}
@@ -45,7 +37,7 @@ func (b *bitmap) remove(x uint32) bool {
 }
 
 func (b *bitmap) index(x uint32) int {
-	return sort.Search(len(b.buf), func(i int) bool { return b.buf[i] == x })
+	return search(b.buf, x)
 }
 
 func (b *bitmap) clone() *bitmap {
@@ -73,11 +65,7 @@ func (b *bitmap) writeTo(w io.Writer) (n int64, err error) {
 		return
 	}
 
-	h1 := *(*hslice)(unsafe.Pointer(&b.buf))
-	h1.l *= 4
-	h1.c *= 4
-	buf1 := *(*[]byte)(unsafe.Pointer(&h1))
-	if n1, err = w.Write(buf1); err != nil {
+	if n1, err = writeSlice(w, b.buf); err != nil {
 		return
 	}
 	n += int64(n1)
@@ -88,21 +76,22 @@ func (b *bitmap) writeTo(w io.Writer) (n int64, err error) {
 func (b *bitmap) readFrom(r io.Reader) (n int64, err error) {
 	var buf [16]byte
 	var n1 int
-	n1, err = r.Read(buf[:])
+	n1, err = readFull(r, buf[:])
 	n += int64(n1)
 	if err != nil {
 		return
 	}
 
 	uniq, ln := binary.LittleEndian.Uint64(buf[0:8]), binary.LittleEndian.Uint64(buf[8:16])
+	// Bitmap contains lower 32 bits of positions.
+	if ln > math.MaxUint32+1 {
+		return n, ErrCorrupted
+	}
+	if b.buf, err = readSlice(r, b.buf, ln); err != nil {
+		return
+	}
+	n += int64(ln) * 4
 	b.uniq = uniq
-	b.buf = make([]uint32, ln)
-	h1 := *(*hslice)(unsafe.Pointer(&b.buf))
-	h1.l *= 4
-	h1.c *= 4
-	buf1 := *(*[]byte)(unsafe.Pointer(&h1))
-	n1, err = r.Read(buf1)
-	n += int64(n1)
 
 	return
 }
@@ -111,3 +100,12 @@ func (b *bitmap) reset() {
 	b.uniq = 0
 	b.buf = b.buf[:0]
 }
+
+// search returns index of x in sorted buf or -(insertion point)-1 if buf doesn't contain x.
+func search(buf []uint32, x uint32) int {
+	i := sort.Search(len(buf), func(i int) bool { return buf[i] >= x })
+	if i < len(buf) && buf[i] == x {
+		return i
+	}
+	return -i - 1
+}
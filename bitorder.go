@@ -0,0 +1,92 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// BitOrder represents order of bits within each byte of the vector.
+type BitOrder uint8
+
+const (
+	// LSBFirst means that position 0 is the least significant bit of the first byte. Default order.
+	LSBFirst BitOrder = iota
+	// MSBFirst means that position 0 is the most significant bit of the first byte. This order uses Redis strings,
+	// BitTorrent bitfields and many binary file formats.
+	MSBFirst
+)
+
+// rev8 is a lookup table of bytes with reversed bits order.
+var rev8 [256]uint8
+
+func init() {
+	for i := 0; i < 256; i++ {
+		rev8[i] = bits.Reverse8(uint8(i))
+	}
+}
+
+// mask returns bit offset mask within a byte, see vector.o.
+func (o BitOrder) mask() uint8 {
+	if o == MSBFirst {
+		return 7
+	}
+	return 0
+}
+
+// ImportBytes makes new vector with given size from buf, which bits are in order src. The vector uses LSB-first order.
+//
+// In opposite to FromBytes the buffer is copied, so the caller may reuse it.
+func ImportBytes(buf []byte, size uint64, src BitOrder) (Interface, error) {
+	if size == 0 {
+		return nil, ErrZeroSize
+	}
+	if uint64(len(buf))*8 < size {
+		return nil, ErrShortBuffer
+	}
+	vec := &vector{
+		buf: make([]uint8, size/8+1),
+		c:   size,
+	}
+	n := copy(vec.buf, buf)
+	if src == MSBFirst {
+		reverseBits(vec.buf[:n], vec.buf[:n])
+	}
+	vec.s = vec.Popcnt()
+	return vec, nil
+}
+
+// ExportBytes appends bytes of the vector in given bits order to dst and returns the extended slice.
+func ExportBytes(dst []byte, vec Interface, order BitOrder) []byte {
+	off, cur := len(dst), LSBFirst
+	if src := bytesOf(vec); src != nil {
+		dst = append(dst, src...)
+		if x, ok := unwrapJournal(vec).(*vector); ok && x.o != 0 {
+			cur = MSBFirst
+		}
+	} else {
+		// Vector has no dense representation, so build bytes from words.
+		it := newWordIter(vec)
+		for i, w, ok := it.next(); ok; i, w, ok = it.next() {
+			dst = appendZeros(dst, off+int(i)*8-len(dst))
+			dst = binary.LittleEndian.AppendUint64(dst, w)
+		}
+		dst = appendZeros(dst, off+int(it.n)*8-len(dst))
+	}
+	if cur != order {
+		reverseBits(dst[off:], dst[off:])
+	}
+	return dst
+}
+
+// reverseBits writes to dst bytes of src with reversed bits order.
+func reverseBits(dst, src []byte) {
+	_ = dst[:len(src)]
+	for i := 0; i < len(src); i++ {
+		dst[i] = rev8[src[i]]
+	}
+}
+
+// reverseBytesBits reverses bits order within each byte of w.
+func reverseBytesBits(w uint64) uint64 {
+	return bits.ReverseBytes64(bits.Reverse64(w))
+}
@@ -0,0 +1,78 @@
+package bitvector
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestBitOrder(t *testing.T) {
+	prepare := func(order BitOrder) Interface {
+		vec, _ := NewVectorWithOrder(20, order)
+		vec.Set(0)
+		vec.Set(3)
+		vec.Set(9)
+		setRange(vec, 12, 18)
+		return vec
+	}
+	t.Run("layout", func(t *testing.T) {
+		if b := bytesOf(prepare(LSBFirst)); !bytes.Equal(b, []byte{0x09, 0xf2, 0x03}) {
+			t.Errorf("unexpected LSB bytes %x", b)
+		}
+		if b := bytesOf(prepare(MSBFirst)); !bytes.Equal(b, []byte{0x90, 0x4f, 0xc0}) {
+			t.Errorf("unexpected MSB bytes %x", b)
+		}
+	})
+	t.Run("iteration", func(t *testing.T) {
+		vec := prepare(MSBFirst)
+		if r := fmt.Sprintf("%v", vec); r != "{0,3,9,12-17}" {
+			t.Errorf("unexpected %s", r)
+		}
+		if vec.Get(9) != 1 || vec.Get(10) != 0 {
+			t.Error("get mismatch")
+		}
+		vec.Unset(9)
+		vec.Xor(10)
+		if r := fmt.Sprintf("%v", vec); r != "{0,3,10,12-17}" {
+			t.Errorf("unexpected %s", r)
+		}
+	})
+	t.Run("ops", func(t *testing.T) {
+		vec := prepare(MSBFirst)
+		if err := vec.Merge(prepare(LSBFirst)); err != ErrBitOrderMismatch {
+			t.Errorf("expected bit order error, got %v", err)
+		}
+		if err := vec.Merge(prepare(MSBFirst)); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("import export", func(t *testing.T) {
+		msb := prepare(MSBFirst)
+		lsb, err := ImportBytes(bytesOf(msb), 20, MSBFirst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if Compare(lsb, msb) != 0 || !bytes.Equal(bytesOf(lsb), bytesOf(prepare(LSBFirst))) {
+			t.Errorf("import mismatch: %v", lsb)
+		}
+		if b := ExportBytes(nil, lsb, MSBFirst); !bytes.Equal(b, bytesOf(msb)) {
+			t.Errorf("export mismatch: %x", b)
+		}
+		if b := ExportBytes(nil, msb, LSBFirst); !bytes.Equal(b, bytesOf(lsb)) {
+			t.Errorf("export mismatch: %x", b)
+		}
+	})
+	t.Run("dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		if _, err := prepare(MSBFirst).WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		vec, _ := NewVector(1)
+		if _, err := vec.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(bytesOf(vec), bytesOf(prepare(MSBFirst))) || vec.Get(9) != 1 {
+			t.Errorf("dump mismatch: %v", vec)
+		}
+	})
+}
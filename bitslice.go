@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"io"
-	"unsafe"
+	"math"
 )
 
 type bitslice struct {
@@ -159,11 +159,7 @@ func (s *bitslice) writeTo(w io.Writer) (n int64, err error) {
 		return
 	}
 
-	h1 := *(*hslice)(unsafe.Pointer(&s.buf))
-	h1.l *= 8
-	h1.c *= 8
-	buf1 := *(*[]byte)(unsafe.Pointer(&h1))
-	if n1, err = w.Write(buf1); err != nil {
+	if n1, err = writeSlice(w, s.buf); err != nil {
 		return
 	}
 	n += int64(n1)
@@ -174,21 +170,21 @@ func (s *bitslice) writeTo(w io.Writer) (n int64, err error) {
 func (s *bitslice) readFrom(r io.Reader) (n int64, err error) {
 	var buf [16]byte
 	var n1 int
-	n1, err = r.Read(buf[:])
+	n1, err = readFull(r, buf[:])
 	n += int64(n1)
 	if err != nil {
 		return
 	}
 
 	ln, ln1 := binary.LittleEndian.Uint64(buf[0:8]), binary.LittleEndian.Uint64(buf[8:16])
+	if ln1 > math.MaxUint64/64 || ln > ln1*64 {
+		return n, ErrCorrupted
+	}
+	if s.buf, err = readSlice(r, s.buf, ln1); err != nil {
+		return
+	}
+	n += int64(ln1) * 8
 	s.ln = ln
-	s.buf = make([]uint64, ln1)
-	h1 := *(*hslice)(unsafe.Pointer(&s.buf))
-	h1.l *= 8
-	h1.c *= 8
-	buf1 := *(*[]byte)(unsafe.Pointer(&h1))
-	n1, err = r.Read(buf1)
-	n += int64(n1)
 
 	return
 }
@@ -0,0 +1,219 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+
+	"github.com/koykov/simd/memset"
+)
+
+const (
+	// Dump header flag of dense vectors: the payload is encoded by blocks, see WriteAdaptive.
+	dumpFlagBlocks = 1 << 3
+
+	// Size of payload block in bytes.
+	dumpBlockSz = 512
+)
+
+// Encodings of payload block.
+const (
+	blockZeros = iota
+	blockOnes
+	blockRaw
+	blockPositions
+	blockRuns
+)
+
+// WriteAdaptive writes dump of the vector like WriteTo, but encodes the payload by blocks of 4096 bits. Each block is
+// stored as the smallest of raw bytes, list of set positions, list of runs of set bits or "all zeros/all ones" marker,
+// so vectors with both dense and sparse regions take about as much space as their roaring counterparts. The dump is
+// read by ReadFrom and Load transparently and decodes to the same dense vector.
+//
+// Roaring vector is sparse by design and is written as WriteTo does.
+func WriteAdaptive(w io.Writer, vec Interface) (int64, error) {
+	return writeDump(w, vec, dumpFlagChecksum|dumpFlagBlocks)
+}
+
+// blockEncoder collects the payload to blocks and writes each block using its smallest encoding.
+//
+// Encoded block starts with encoding type and 2-byte length of raw block data. Positions and runs are bit offsets
+// within the block data, both prefixed by 2-byte count. Run is a pair of offset and length.
+type blockEncoder struct {
+	w   io.Writer
+	buf [dumpBlockSz]byte
+	n   int
+	out []byte
+}
+
+func (e *blockEncoder) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		k := copy(e.buf[e.n:], p)
+		e.n += k
+		p, n = p[k:], n+k
+		if e.n == dumpBlockSz {
+			if err = e.flush(); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// flush encodes and writes collected block.
+func (e *blockEncoder) flush() error {
+	if e.n == 0 {
+		return nil
+	}
+	b := e.buf[:e.n]
+	e.n = 0
+
+	var pop, runs int
+	var prev uint8
+	for i := 0; i < len(b); i++ {
+		pop += bits.OnesCount8(b[i])
+		// Run starts at set bit which has clear previous bit.
+		runs += bits.OnesCount8(b[i] &^ (b[i]<<1 | prev>>7))
+		prev = b[i]
+	}
+	typ, size := blockRaw, len(b)
+	switch {
+	case pop == 0:
+		typ = blockZeros
+	case pop == len(b)*8:
+		typ = blockOnes
+	case 2+2*pop < size && pop <= 2*runs:
+		typ = blockPositions
+	case 2+4*runs < size:
+		typ = blockRuns
+	}
+
+	out := append(e.out[:0], uint8(typ))
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(b)))
+	switch typ {
+	case blockRaw:
+		out = append(out, b...)
+	case blockPositions:
+		out = binary.LittleEndian.AppendUint16(out, uint16(pop))
+		for i := 0; i < len(b); i++ {
+			for x := b[i]; x != 0; x &= x - 1 {
+				out = binary.LittleEndian.AppendUint16(out, uint16(i*8+bits.TrailingZeros8(x)))
+			}
+		}
+	case blockRuns:
+		out = binary.LittleEndian.AppendUint16(out, uint16(runs))
+		lo := -1
+		for i := 0; i <= len(b)*8; i++ {
+			set := i < len(b)*8 && b[i/8]&(1<<(i%8)) != 0
+			switch {
+			case set && lo < 0:
+				lo = i
+			case !set && lo >= 0:
+				out = binary.LittleEndian.AppendUint16(out, uint16(lo))
+				out = binary.LittleEndian.AppendUint16(out, uint16(i-lo))
+				lo = -1
+			}
+		}
+	}
+	e.out = out
+	_, err := e.w.Write(out)
+	return err
+}
+
+// encodedLimit returns max size of the payload of raw size n encoded according to dump flags.
+func encodedLimit(n, flags uint64) uint64 {
+	if flags&dumpFlagBlocks == 0 {
+		return n
+	}
+	// Every block takes at most the headers and the longest list of positions or runs.
+	const blockMax = 5 + dumpBlockSz*16
+	k := n/dumpBlockSz + 1
+	if k > math.MaxUint64/blockMax {
+		return math.MaxUint64
+	}
+	return k * blockMax
+}
+
+// blockDecoder reads blocks written by blockEncoder and returns raw payload.
+type blockDecoder struct {
+	r      io.Reader
+	buf    [dumpBlockSz]byte
+	off, n int
+	// Space for positions or runs of a block.
+	lst [dumpBlockSz * 16]byte
+}
+
+func (d *blockDecoder) Read(p []byte) (n int, err error) {
+	if d.off == d.n {
+		if err = d.next(); err != nil {
+			return
+		}
+	}
+	n = copy(p, d.buf[d.off:d.n])
+	d.off += n
+	return
+}
+
+// next decodes the next block.
+func (d *blockDecoder) next() error {
+	var hdr [3]byte
+	if _, err := readFull(d.r, hdr[:]); err != nil {
+		return err
+	}
+	typ, ln := hdr[0], int(binary.LittleEndian.Uint16(hdr[1:]))
+	if ln == 0 || ln > dumpBlockSz {
+		return ErrCorrupted
+	}
+	b := d.buf[:ln]
+	switch typ {
+	case blockZeros:
+		clear(b)
+	case blockOnes:
+		memset.Memset(b, 0xff)
+	case blockRaw:
+		if _, err := readFull(d.r, b); err != nil {
+			return err
+		}
+	case blockPositions, blockRuns:
+		if _, err := readFull(d.r, hdr[:2]); err != nil {
+			return err
+		}
+		k, sz := int(binary.LittleEndian.Uint16(hdr[:2])), 2
+		if typ == blockRuns {
+			sz = 4
+		}
+		if k*sz > len(d.lst) {
+			return ErrCorrupted
+		}
+		lst := d.lst[:k*sz]
+		if _, err := readFull(d.r, lst); err != nil {
+			return err
+		}
+		clear(b)
+		for i := 0; i < len(lst); i += sz {
+			lo, hi := int(binary.LittleEndian.Uint16(lst[i:])), 0
+			if hi = lo + 1; typ == blockRuns {
+				hi = lo + int(binary.LittleEndian.Uint16(lst[i+2:]))
+			}
+			if hi <= lo || hi > ln*8 {
+				return ErrCorrupted
+			}
+			for j := lo; j < hi; j++ {
+				b[j/8] |= 1 << (j % 8)
+			}
+		}
+	default:
+		return ErrCorrupted
+	}
+	d.off, d.n = 0, ln
+	return nil
+}
+
+// done checks that the payload has been consumed completely.
+func (d *blockDecoder) done() error {
+	if d.off != d.n {
+		return ErrCorrupted
+	}
+	return nil
+}
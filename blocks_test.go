@@ -0,0 +1,97 @@
+package bitvector
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBlocks(t *testing.T) {
+	// Mixed regions: sparse positions, long runs, full and empty blocks and noise.
+	prepare := func(kind Kind) Interface {
+		vec, _ := newOfKind(kind, 1<<16, 0)
+		for i := uint64(0); i < 4096; i += 97 {
+			vec.Set(i)
+		}
+		setRange(vec, 4096+100, 4096+3000)
+		setRange(vec, 3*4096, 5*4096)
+		for i, x := uint64(6*4096), uint64(1); i < 7*4096; i++ {
+			if x = x*6364136223846793005 + 1442695040888963407; x>>63 != 0 {
+				vec.Set(i)
+			}
+		}
+		vec.Set(1<<16 - 1)
+		return vec
+	}
+	t.Run("round trip", func(t *testing.T) {
+		for _, kind := range []Kind{KindVector, KindConcurrentVector} {
+			vec := prepare(kind)
+			var raw, buf bytes.Buffer
+			_, _ = vec.WriteTo(&raw)
+			n, err := WriteAdaptive(&buf, vec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(buf.Len()) || buf.Len()*2 > raw.Len() {
+				t.Errorf("%s: unexpected size %d (reported %d) of %d bytes", kind, buf.Len(), n, raw.Len())
+			}
+			vec1, _ := newOfKind(kind, 1, 0)
+			if _, err = vec1.ReadFrom(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if CompareNumeric(vec, vec1) != 0 || vec.Popcnt() != vec1.Popcnt() {
+				t.Errorf("%s: vectors mismatch", kind)
+			}
+		}
+	})
+	t.Run("msb first", func(t *testing.T) {
+		vec, _ := NewVectorWithOrder(100, MSBFirst)
+		vec.Set(3)
+		setRange(vec, 10, 90)
+		var buf bytes.Buffer
+		_, _ = WriteAdaptive(&buf, vec)
+		vec1, err := Load(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(bytesOf(vec), bytesOf(vec1)) || CompareNumeric(vec, vec1) != 0 {
+			t.Errorf("got %v, want %v", vec1, vec)
+		}
+	})
+	t.Run("compressed", func(t *testing.T) {
+		vec := prepare(KindVector).(*vector)
+		var buf bytes.Buffer
+		if _, err := vec.dump(&buf, dumpFlagChecksum|dumpFlagCompressed|dumpFlagBlocks); err != nil {
+			t.Fatal(err)
+		}
+		h, _ := Inspect(bytes.NewReader(buf.Bytes()))
+		vec1, err := Load(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Flags&(FlagBlocks|FlagCompressed) != FlagBlocks|FlagCompressed || CompareNumeric(vec, vec1) != 0 {
+			t.Errorf("vectors mismatch, header %+v", h)
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		vec, _ := Parse("3,5,100-900,3000", KindVector)
+		var buf bytes.Buffer
+		_, _ = WriteAdaptive(&buf, vec)
+		p := buf.Bytes()
+		for i := 1; i < len(p); i++ {
+			vec1, _ := NewVector(1)
+			if _, err := vec1.ReadFrom(bytes.NewReader(p[:i])); err != ErrTruncated {
+				t.Errorf("%d bytes: expected truncated error, got %v", i, err)
+			}
+		}
+	})
+	t.Run("corrupted", func(t *testing.T) {
+		d := blockDecoder{r: bytes.NewReader([]byte{blockPositions, 1, 0, 1, 0, 8, 0})}
+		if _, err := d.Read(make([]byte, 1)); err != ErrCorrupted {
+			t.Errorf("expected corrupted error, got %v", err)
+		}
+		d = blockDecoder{r: bytes.NewReader([]byte{blockRuns, 2, 0, 1, 0, 4, 0, 0, 0})}
+		if _, err := d.Read(make([]byte, 1)); err != ErrCorrupted {
+			t.Errorf("expected corrupted error, got %v", err)
+		}
+	})
+}
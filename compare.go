@@ -0,0 +1,64 @@
+package bitvector
+
+import (
+	"cmp"
+	"math/bits"
+)
+
+// Compare compares two vectors lexicographically by their sequences of set positions.
+//
+// The result is 0 if a and b have the same set bits, -1 if a < b and +1 if a > b. A vector which positions are the
+// prefix of the other's positions is the lesser, so an empty vector precedes any other. Capacities are not taken into
+// account. Comparison is made word-wise and stops at the first different word, thus the function is cheap enough to
+// use with slices.SortFunc.
+func Compare(a, b Interface) int {
+	ia, ib := newWordIter(a), newWordIter(b)
+	for {
+		i, x, oka := ia.next()
+		j, y, okb := ib.next()
+		switch {
+		case !oka && !okb:
+			return 0
+		case !oka:
+			// Positions of a are the prefix of b's positions.
+			return -1
+		case !okb:
+			return 1
+		case i < j:
+			// Word i contains the first different position, which only a has, and b has positions after it.
+			return -1
+		case i > j:
+			return 1
+		case x == y:
+			continue
+		}
+		// All positions below k are equal and exactly one vector has the bit k set.
+		k := bits.TrailingZeros64(x ^ y)
+		above := ^uint64(0) << k << 1
+		if x&(1<<k) != 0 {
+			// Position k goes first in a, so a is the lesser unless b has no positions after k.
+			if _, _, more := ib.next(); y&above != 0 || more {
+				return -1
+			}
+			return 1
+		}
+		if _, _, more := ia.next(); x&above != 0 || more {
+			return 1
+		}
+		return -1
+	}
+}
+
+// CompareNumeric compares two vectors as unsigned big integers, where bit at position i has weight 2^i.
+//
+// The result is 0 if a == b, -1 if a < b and +1 if a > b. The most significant different word defines the result.
+func CompareNumeric(a, b Interface) int {
+	var r int
+	mergeWords(a, b, func(_, x, y uint64) bool {
+		if x != y {
+			r = cmp.Compare(x, y)
+		}
+		return true
+	})
+	return r
+}
@@ -0,0 +1,86 @@
+package bitvector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	prepare := func(size uint64, pos ...uint64) Interface {
+		vec, _ := NewVector(size)
+		for _, p := range pos {
+			vec.Set(p)
+		}
+		return vec
+	}
+	prepareCn := func(size uint64, pos ...uint64) Interface {
+		vec, _ := NewConcurrentVector(size, 0)
+		for _, p := range pos {
+			vec.Set(p)
+		}
+		return vec
+	}
+	t.Run("lexicographic", func(t *testing.T) {
+		stages := []struct {
+			a, b Interface
+			want int
+		}{
+			{prepare(10), prepare(10), 0},
+			{prepare(10, 3, 5), prepare(100, 3, 5), 0},
+			{prepare(10, 1, 5), prepare(10, 2), -1},
+			{prepare(10, 1), prepare(10, 1, 5), -1},
+			{prepare(10, 1, 5), prepare(10, 1), 1},
+			{prepare(10), prepare(10, 9), -1},
+			{prepare(200, 3, 150), prepare(200, 3, 70), 1},
+			{prepare(200, 63, 64), prepare(200, 63), 1},
+			{prepare(200, 63), prepare(200, 63, 199), -1},
+			{prepare(200, 5, 130), prepareCn(200, 5, 130), 0},
+			{prepareCn(200, 5, 130), prepareCn(200, 5, 129), 1},
+		}
+		for i, st := range stages {
+			if r := Compare(st.a, st.b); r != st.want {
+				t.Errorf("stage #%d: got %d, want %d", i, r, st.want)
+			}
+		}
+	})
+	t.Run("numeric", func(t *testing.T) {
+		stages := []struct {
+			a, b Interface
+			want int
+		}{
+			{prepare(10), prepare(100), 0},
+			{prepare(10, 1, 5), prepare(10, 2), 1},
+			{prepare(10, 1), prepare(10, 1, 5), -1},
+			{prepare(200, 150), prepare(200, 0, 1, 2, 149), 1},
+			{prepare(10, 7), prepare(300, 7, 299), -1},
+			{prepareCn(200, 64), prepare(200, 63), 1},
+		}
+		for i, st := range stages {
+			if r := CompareNumeric(st.a, st.b); r != st.want {
+				t.Errorf("stage #%d: got %d, want %d", i, r, st.want)
+			}
+		}
+	})
+	t.Run("sort", func(t *testing.T) {
+		vecs := []Interface{prepare(10, 4), prepare(10, 1, 9), prepare(10), prepare(10, 1)}
+		slices.SortFunc(vecs, Compare)
+		want := []Interface{prepare(10), prepare(10, 1), prepare(10, 1, 9), prepare(10, 4)}
+		for i := range vecs {
+			if Compare(vecs[i], want[i]) != 0 {
+				t.Errorf("position %d: unexpected order", i)
+			}
+		}
+	})
+}
+
+func BenchmarkCompare(b *testing.B) {
+	b.ReportAllocs()
+	const size = 1e6
+	vec0, _ := NewVector(size)
+	vec1, _ := NewVector(size)
+	vec0.Set(size - 1)
+	vec1.Set(size - 2)
+	for i := 0; i < b.N; i++ {
+		Compare(vec0, vec1)
+	}
+}
@@ -0,0 +1,156 @@
+package bitvector
+
+import (
+	"compress/flate"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	// Dump header flag common for all vector types: the payload is compressed.
+	dumpFlagCompressed = 1 << 2
+
+	// Max size of frame of compressed payload.
+	frameSz = 64 << 10
+)
+
+// WriteCompressed writes dump of the vector like WriteTo, but compresses the payload using DEFLATE. Header stays
+// uncompressed, thus Inspect works with compressed dumps as well. The dump is read by ReadFrom and Load transparently.
+//
+// The payload is compressed while being written, so the function doesn't need a copy of the vector in memory.
+func WriteCompressed(w io.Writer, vec Interface) (int64, error) {
+	return writeDump(w, vec, dumpFlagChecksum|dumpFlagCompressed)
+}
+
+// compressWriter returns writer compressing the payload and the function completing it.
+func compressWriter(w io.Writer) (io.Writer, func() error) {
+	fw := &frameWriter{w: w}
+	zw, _ := flate.NewWriter(fw, flate.BestSpeed)
+	return zw, func() error {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		return fw.close()
+	}
+}
+
+// compressReader returns reader decompressing the payload and the function consuming the rest of it.
+//
+// Decompressed data exceeding limit bytes is reported as ErrCorrupted, so small compressed input can't inflate beyond
+// the size promised by the header.
+func compressReader(r io.Reader, limit uint64) (io.Reader, func() error) {
+	fr := &frameReader{r: r}
+	zr := flate.NewReader(fr)
+	return &limitReader{r: zr, n: limit}, func() error {
+		var buf [1]byte
+		if n, err := zr.Read(buf[:]); n > 0 {
+			return ErrCorrupted
+		} else if err != io.EOF {
+			return truncated(err)
+		}
+		if err := zr.Close(); err != nil {
+			return err
+		}
+		// Decompressor may stop before the terminating frame.
+		_, err := io.Copy(io.Discard, fr)
+		return err
+	}
+}
+
+// limitReader reads up to n bytes and fails with ErrCorrupted if the underlying reader has more data.
+type limitReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (r *limitReader) Read(p []byte) (n int, err error) {
+	if uint64(len(p)) > r.n {
+		// One extra byte detects the excess.
+		p = p[:r.n+1]
+	}
+	n, err = r.r.Read(p)
+	if uint64(n) > r.n {
+		return 0, ErrCorrupted
+	}
+	r.n -= uint64(n)
+	return
+}
+
+// frameWriter splits compressed payload to frames prefixed by 4-byte length. Zero-length frame terminates the payload.
+//
+// Framing allows to read compressed payload exactly to its end, whereas decompressor may read ahead.
+type frameWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (w *frameWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if w.buf == nil {
+			w.buf = make([]byte, 4, frameSz+4)
+		}
+		k := min(len(p), frameSz+4-len(w.buf))
+		w.buf = append(w.buf, p[:k]...)
+		p, n = p[k:], n+k
+		if len(w.buf) == frameSz+4 {
+			if err = w.flush(); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (w *frameWriter) flush() error {
+	if len(w.buf) <= 4 {
+		return nil
+	}
+	binary.LittleEndian.PutUint32(w.buf, uint32(len(w.buf)-4))
+	_, err := w.w.Write(w.buf)
+	w.buf = w.buf[:4]
+	return err
+}
+
+func (w *frameWriter) close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	var buf [4]byte
+	_, err := w.w.Write(buf[:])
+	return err
+}
+
+// frameReader reads frames written by frameWriter and returns io.EOF after the terminating frame.
+type frameReader struct {
+	r   io.Reader
+	rem int
+	eof bool
+}
+
+func (r *frameReader) Read(p []byte) (n int, err error) {
+	if r.rem == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		var buf [4]byte
+		if _, err = readFull(r.r, buf[:]); err != nil {
+			return
+		}
+		if r.rem = int(binary.LittleEndian.Uint32(buf[:])); r.rem > frameSz {
+			return 0, ErrCorrupted
+		}
+		if r.rem == 0 {
+			r.eof = true
+			return 0, io.EOF
+		}
+	}
+	n, err = r.r.Read(p[:min(len(p), r.rem)])
+	r.rem -= n
+	if err == io.EOF {
+		err = nil
+		if n == 0 {
+			err = ErrTruncated
+		}
+	}
+	return
+}
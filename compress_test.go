@@ -0,0 +1,100 @@
+package bitvector
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCompress(t *testing.T) {
+	kinds := []Kind{KindVector, KindConcurrentVector, KindRoaringVector}
+	t.Run("round trip", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse("3,5,7-9,100,1000000", kind)
+			var raw, buf bytes.Buffer
+			_, _ = vec.WriteTo(&raw)
+			n, err := WriteCompressed(&buf, vec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(buf.Len()) {
+				t.Errorf("%s: written %d bytes, reported %d", kind, buf.Len(), n)
+			}
+			if kind != KindRoaringVector && buf.Len()*10 > raw.Len() {
+				t.Errorf("%s: poor compression %d of %d bytes", kind, buf.Len(), raw.Len())
+			}
+			// Trailing data must stay in the stream.
+			buf.WriteString("tail")
+			vec1, _ := newOfKind(kind, 1, 0)
+			if _, err = vec1.ReadFrom(iotest.OneByteReader(&buf)); err != nil {
+				t.Fatal(err)
+			}
+			if Compare(vec, vec1) != 0 {
+				t.Errorf("%s: got %v, want %v", kind, vec1, vec)
+			}
+			if buf.String() != "tail" {
+				t.Errorf("%s: unexpected rest of stream %q", kind, buf.String())
+			}
+		}
+	})
+	t.Run("large", func(t *testing.T) {
+		vec, _ := NewConcurrentVector(1<<24, 0)
+		for i := uint64(0); i < 1<<24; i += 4097 {
+			vec.Set(i)
+		}
+		var buf bytes.Buffer
+		if _, err := WriteCompressed(&buf, vec.(Freezer).Freeze()); err != nil {
+			t.Fatal(err)
+		}
+		vec1, err := Load(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if CompareNumeric(vec, vec1) != 0 {
+			t.Error("vectors mismatch")
+		}
+	})
+	t.Run("inspect", func(t *testing.T) {
+		vec, _ := Parse("3,5", KindVector)
+		var buf bytes.Buffer
+		_, _ = WriteCompressed(&buf, vec)
+		h, err := Inspect(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Flags&FlagCompressed == 0 || h.HeaderLen+h.PayloadLen != int64(buf.Len()) {
+			t.Errorf("unexpected header %+v", h)
+		}
+	})
+	t.Run("bounded", func(t *testing.T) {
+		// Payload inflates far beyond the size promised by the header.
+		var buf bytes.Buffer
+		_ = writeHeader(&buf, vectorDumpSignature, dumpFlagCompressed, nil, 64, 0)
+		w, done := compressWriter(&buf)
+		_, _ = w.Write(make([]byte, 1<<20))
+		_ = done()
+		p := buf.Bytes()
+		r, _ := compressReader(bytes.NewReader(p[40:]), 9)
+		if n, err := io.Copy(io.Discard, r); err != ErrCorrupted || n > 9 {
+			t.Errorf("read %d bytes, error %v", n, err)
+		}
+		if _, err := Load(bytes.NewReader(p)); err != ErrCorrupted {
+			t.Errorf("expected corrupted error, got %v", err)
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse("3,5,7-9,100", kind)
+			var buf bytes.Buffer
+			_, _ = WriteCompressed(&buf, vec)
+			p := buf.Bytes()
+			for i := 1; i < len(p); i++ {
+				vec1, _ := newOfKind(kind, 1, 0)
+				if _, err := vec1.ReadFrom(bytes.NewReader(p[:i])); err != ErrTruncated {
+					t.Errorf("%s of %d bytes: expected truncated error, got %v", kind, i, err)
+				}
+			}
+		}
+	})
+}
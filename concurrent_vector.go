@@ -3,14 +3,17 @@ package bitvector
 import (
 	"encoding/binary"
 	"io"
+	"maps"
 	"math"
 	"math/bits"
 	"sync/atomic"
+	"unsafe"
 )
 
 const (
 	cnVectorDumpSignature = 0xe1aa38d7f1fe3cd9
-	cnVectorDumpVersion   = 1.0
+	cnVectorDumpVersion1  = 1.0
+	cnVectorDumpVersion2  = 2.0
 
 	blockSz = 4096
 )
@@ -22,6 +25,10 @@ type concurrentVector struct {
 	blk  [blockSz]byte
 	lim  uint64
 	c, s uint64
+	ro   uint32
+	md   Metadata
+	// Buffer is owned by the caller, see ConcurrentFromUint32.
+	borrowed bool
 }
 
 // NewConcurrentVector make new concurrent bit array with given size. Param writeAttemptsLimit is the maximum number of
@@ -37,9 +44,54 @@ func NewConcurrentVector(size, writeAttemptsLimit uint64) (Interface, error) {
 	}, nil
 }
 
+// ConcurrentFromUint32 makes new concurrent bit array over given buffer without copying.
+//
+// The vector borrows buf: all modifications of the vector are visible in buf and vice versa. The caller must not
+// modify buf (except using atomics) or release it while the vector is in use. Buffer must contain at least size bits.
+func ConcurrentFromUint32(buf []uint32, size, writeAttemptsLimit uint64) (Interface, error) {
+	if size == 0 {
+		return nil, ErrZeroSize
+	}
+	if uint64(len(buf))*32 < size {
+		return nil, ErrShortBuffer
+	}
+	vec := &concurrentVector{
+		buf:      buf,
+		lim:      writeAttemptsLimit + 1,
+		c:        size,
+		borrowed: true,
+	}
+	vec.s = vec.Popcnt()
+	return vec, nil
+}
+
+// ConcurrentFromUint64 makes new concurrent bit array over given buffer without copying.
+//
+// Same ownership rules as in ConcurrentFromUint32 are applied. Bit at position i is i%64 bit of buf[i/64]. On
+// big-endian hosts halves of words are swapped in memory, so buf is copied instead of borrowing.
+func ConcurrentFromUint64(buf []uint64, size, writeAttemptsLimit uint64) (Interface, error) {
+	if len(buf) == 0 {
+		return ConcurrentFromUint32(nil, size, writeAttemptsLimit)
+	}
+	if !hostLE {
+		buf32 := make([]uint32, len(buf)*2)
+		for i, w := range buf {
+			buf32[i*2], buf32[i*2+1] = uint32(w), uint32(w>>32)
+		}
+		vec, err := ConcurrentFromUint32(buf32, size, writeAttemptsLimit)
+		if err == nil {
+			vec.(*concurrentVector).borrowed = false
+		}
+		return vec, err
+	}
+	h := hslice{p: uintptr(unsafe.Pointer(&buf[0])), l: len(buf) * 2, c: len(buf) * 2}
+	buf32 := *(*[]uint32)(unsafe.Pointer(&h))
+	return ConcurrentFromUint32(buf32, size, writeAttemptsLimit)
+}
+
 // Set writes new bit at given position.
 func (vec *concurrentVector) Set(i uint64) bool {
-	if len(vec.buf) <= int(i/32) {
+	if vec.frozen() || len(vec.buf) <= int(i/32) {
 		return false
 	}
 	for j := uint64(0); j < vec.lim; j++ {
@@ -53,9 +105,31 @@ func (vec *concurrentVector) Set(i uint64) bool {
 	return false
 }
 
+// SetRange writes bits in range [lo, hi).
+func (vec *concurrentVector) SetRange(lo, hi uint64) bool {
+	if vec.frozen() || lo > hi || uint64(len(vec.buf))*32 < hi {
+		return false
+	}
+	for lo < hi {
+		i, n := lo/32, min(hi-lo, 32-lo%32)
+		mask := uint32(math.MaxUint32) >> (32 - n) << (lo % 32)
+		var ok bool
+		for j := uint64(0); j < vec.lim && !ok; j++ {
+			o := atomic.LoadUint32(&vec.buf[i])
+			ok = atomic.CompareAndSwapUint32(&vec.buf[i], o, o|mask)
+		}
+		if !ok {
+			return false
+		}
+		atomic.AddUint64(&vec.s, n)
+		lo += n
+	}
+	return true
+}
+
 // Xor applies xor at given position.
 func (vec *concurrentVector) Xor(i uint64) bool {
-	if len(vec.buf) <= int(i/32) {
+	if vec.frozen() || len(vec.buf) <= int(i/32) {
 		return false
 	}
 	for j := uint64(0); j < vec.lim; j++ {
@@ -71,7 +145,7 @@ func (vec *concurrentVector) Xor(i uint64) bool {
 
 // Unset clears bit at given position.
 func (vec *concurrentVector) Unset(i uint64) bool {
-	if len(vec.buf) <= int(i/32) {
+	if vec.frozen() || len(vec.buf) <= int(i/32) {
 		return false
 	}
 	for j := uint64(0); j < vec.lim; j++ {
@@ -119,9 +193,11 @@ func (vec *concurrentVector) Popcnt() (r uint64) {
 
 func (vec *concurrentVector) Difference(other Interface) (r uint64, err error) {
 	var ovec *concurrentVector
-	switch x := any(other).(type) {
+	switch x := unwrapJournal(other).(type) {
 	case *concurrentVector:
 		ovec = x
+	case *frozenVector:
+		ovec = x.src
 	default:
 		err = ErrWrongType
 		return
@@ -152,10 +228,15 @@ func (vec *concurrentVector) Filter(other Interface) error {
 }
 
 func (vec *concurrentVector) bitwise(other Interface, fn func(a, b uint32) uint32) error {
+	if vec.frozen() {
+		return ErrFrozen
+	}
 	var ovec *concurrentVector
-	switch x := any(other).(type) {
+	switch x := unwrapJournal(other).(type) {
 	case *concurrentVector:
 		ovec = x
+	case *frozenVector:
+		ovec = x.src
 	default:
 		return ErrWrongType
 	}
@@ -180,7 +261,7 @@ func (vec *concurrentVector) bitwise(other Interface, fn func(a, b uint32) uint3
 
 func (vec *concurrentVector) Invert() {
 	n := len(vec.buf)
-	if n == 0 {
+	if n == 0 || vec.frozen() {
 		return
 	}
 	_ = vec.buf[n-1]
@@ -201,6 +282,7 @@ func (vec *concurrentVector) Clone() Interface {
 		c:   vec.c,
 		s:   atomic.LoadUint64(&vec.s),
 		lim: vec.lim,
+		md:  maps.Clone(vec.md),
 	}
 	for i := 0; i < len(vec.buf); i++ {
 		atomic.StoreUint32(&clone.buf[i], atomic.LoadUint32(&vec.buf[i]))
@@ -211,7 +293,7 @@ func (vec *concurrentVector) Clone() Interface {
 // Reset resets the whole bit array.
 func (vec *concurrentVector) Reset() {
 	n := len(vec.buf)
-	if n == 0 {
+	if n == 0 || vec.frozen() {
 		return
 	}
 	_ = vec.buf[n-1]
@@ -220,87 +302,150 @@ func (vec *concurrentVector) Reset() {
 	}
 }
 
-func (vec *concurrentVector) ReadFrom(r io.Reader) (n int64, err error) {
-	var (
-		buf [40]byte
-		m   int
-	)
-	m, err = r.Read(buf[:])
-	n += int64(m)
-	if err != nil {
-		return n, err
+// Freeze makes the vector read-only and returns its immutable view.
+//
+// All further modifications of the vector will fail. The view reads buffer of the vector without atomics, on big-endian
+// hosts it reads the little-endian copy, see Bytes. Freeze must be called after all writers have finished. Use Clone
+// to get mutable copy of frozen vector.
+func (vec *concurrentVector) Freeze() Interface {
+	atomic.StoreUint32(&vec.ro, 1)
+	return &frozenVector{
+		vector: vector{
+			buf: vec.Bytes(),
+			c:   vec.c,
+			s:   atomic.LoadUint64(&vec.s),
+			ro:  true,
+		},
+		src: vec,
 	}
+}
 
-	sign, ver, c, s, lim := binary.LittleEndian.Uint64(buf[0:8]), binary.LittleEndian.Uint64(buf[8:16]),
-		binary.LittleEndian.Uint64(buf[16:24]), binary.LittleEndian.Uint64(buf[24:32]),
-		binary.LittleEndian.Uint64(buf[32:40])
+func (vec *concurrentVector) frozen() bool {
+	return atomic.LoadUint32(&vec.ro) == 1
+}
 
-	if sign != cnVectorDumpSignature {
-		return n, ErrInvalidSignature
+// Bytes returns underlying buffer of the vector. Bit at position i is i%8 bit of byte i/8.
+//
+// Buffer shares memory with the vector, so it must be read with care during simultaneous writes. On big-endian hosts
+// memory of words has another byte order, so the little-endian copy of words returns instead.
+func (vec *concurrentVector) Bytes() []byte {
+	if len(vec.buf) == 0 {
+		return nil
 	}
-	if ver != math.Float64bits(cnVectorDumpVersion) {
-		return n, ErrVersionMismatch
+	if !hostLE {
+		p := make([]byte, len(vec.buf)*4)
+		for i := range vec.buf {
+			binary.LittleEndian.PutUint32(p[i*4:], atomic.LoadUint32(&vec.buf[i]))
+		}
+		return p
 	}
-	vec.c, vec.lim = c, lim
-	atomic.StoreUint64(&vec.s, s)
+	h := hslice{p: uintptr(unsafe.Pointer(&vec.buf[0])), l: len(vec.buf) * 4, c: len(vec.buf) * 4}
+	return *(*[]byte)(unsafe.Pointer(&h))
+}
 
-	if cp := c/32 + 1; uint64(len(vec.buf)) < cp {
-		vec.buf = make([]uint32, cp)
+func (vec *concurrentVector) ReadFrom(r io.Reader) (n int64, err error) {
+	if vec.frozen() {
+		return 0, ErrFrozen
+	}
+	cr := crcReader{r: r}
+	defer func() { n = cr.n }()
+	h, err := readHeader(&cr, cnVectorDumpSignature)
+	if err != nil {
+		return
+	}
+	c, s, lim, flags := h.fields[0], h.fields[1], h.fields[2], h.flags
+	if flags&^(dumpFlagChecksum|dumpFlagCompressed|dumpFlagBlocks|dumpFlagMetadata) != 0 {
+		return n, ErrUnknownFlags
 	}
 
-	for i := 0; ; i += blockSz {
-		m, err = r.Read(vec.blk[:])
-		n += int64(m)
-		if err != nil && err != io.EOF {
-			return n, err
+	// Read in place if the buffer is large enough, otherwise collect payload to new buffer while the data arrives.
+	cp := c/32 + 1
+	pr, done := payloadReader(&cr, flags, cp*4)
+	dst := vec.buf
+	inplace := uint64(len(dst)) >= cp
+	if !inplace {
+		dst = nil
+	}
+	for i := uint64(0); i < cp; {
+		k := min(cp-i, blockSz/4)
+		if _, err = readFull(pr, vec.blk[:k*4]); err != nil {
+			break
 		}
-		for j := 0; j < m; j += 4 {
-			v := binary.LittleEndian.Uint32(vec.blk[j:])
-			atomic.StoreUint32(&vec.buf[(i+j)/4], v)
+		for j := uint64(0); j < k; j++ {
+			v := binary.LittleEndian.Uint32(vec.blk[j*4:])
+			if inplace {
+				atomic.StoreUint32(&dst[i+j], v)
+			} else {
+				dst = append(dst, v)
+			}
 		}
-		if err == io.EOF {
-			err = nil
-			break
+		i += k
+	}
+	if err == nil {
+		err = done()
+	}
+	if err == nil && flags&dumpFlagChecksum != 0 {
+		err = cr.readChecksum()
+	}
+	if err != nil {
+		// Buffer read in place is reset if the dump turns out broken.
+		if inplace {
+			for i := range dst {
+				atomic.StoreUint32(&dst[i], 0)
+			}
+			atomic.StoreUint64(&vec.s, 0)
+		}
+		return
+	}
+	if inplace {
+		for i := cp; i < uint64(len(dst)); i++ {
+			atomic.StoreUint32(&dst[i], 0)
 		}
+	} else {
+		vec.buf = dst
 	}
+	vec.c, vec.lim, vec.md = c, lim, h.md
+	atomic.StoreUint64(&vec.s, s)
 	return
 }
 
-func (vec *concurrentVector) WriteTo(w io.Writer) (n int64, err error) {
-	var (
-		buf [40]byte
-		m   int
-	)
-	binary.LittleEndian.PutUint64(buf[0:8], cnVectorDumpSignature)
-	binary.LittleEndian.PutUint64(buf[8:16], math.Float64bits(cnVectorDumpVersion))
-	binary.LittleEndian.PutUint64(buf[16:24], vec.c)
-	binary.LittleEndian.PutUint64(buf[24:32], atomic.LoadUint64(&vec.s))
-	binary.LittleEndian.PutUint64(buf[32:40], vec.lim)
-	if m, err = w.Write(buf[:]); err != nil {
-		return int64(m), err
-	}
-	n += int64(m)
+func (vec *concurrentVector) WriteTo(w io.Writer) (int64, error) {
+	return vec.dump(w, dumpFlagChecksum)
+}
+
+// dump writes the vector with given dump flags.
+func (vec *concurrentVector) dump(w io.Writer, flags uint64) (int64, error) {
+	cw := crcWriter{w: w}
+	if err := writeHeader(&cw, cnVectorDumpSignature, flags, vec.md, vec.c, atomic.LoadUint64(&vec.s),
+		vec.lim); err != nil {
+		return cw.n, err
+	}
 
+	// Payload is always c/32+1 words, borrowed buffer may be shorter or longer.
+	pw, done := payloadWriter(&cw, flags)
 	var off int
-	for i := 0; i < len(vec.buf); i++ {
-		v := atomic.LoadUint32(&vec.buf[i])
+	for i := 0; i < int(vec.c/32+1); i++ {
+		var v uint32
+		if i < len(vec.buf) {
+			v = atomic.LoadUint32(&vec.buf[i])
+		}
 		binary.LittleEndian.PutUint32(vec.blk[off:], v)
 		if off += 4; off == blockSz {
-			m, err = w.Write(vec.blk[:off])
-			n += int64(m)
-			if err != nil {
-				return
-			}
-			if m < blockSz {
-				err = io.ErrShortWrite
-				return
+			if _, err := pw.Write(vec.blk[:off]); err != nil {
+				return cw.n, err
 			}
 			off = 0
 		}
 	}
 	if off > 0 {
-		m, err = w.Write(vec.blk[:off])
-		n += int64(m)
+		if _, err := pw.Write(vec.blk[:off]); err != nil {
+			return cw.n, err
+		}
 	}
-	return
+	if err := done(); err != nil {
+		return cw.n, err
+	}
+
+	err := cw.writeChecksum()
+	return cw.n, err
 }
@@ -110,6 +110,60 @@ func TestConcurrentVector(t *testing.T) {
 			t.FailNow()
 		}
 	})
+	t.Run("set range", func(t *testing.T) {
+		stages := []struct{ lo, hi uint64 }{{0, 0}, {3, 6}, {0, 32}, {5, 30}, {31, 33}, {1, 60}, {0, 64}}
+		for _, st := range stages {
+			vec, _ := NewConcurrentVector(60, 0)
+			if !setRange(vec, st.lo, st.hi) {
+				t.Errorf("range [%d, %d) wasn't set", st.lo, st.hi)
+			}
+			for i := uint64(0); i < vec.Capacity(); i++ {
+				if want := i >= st.lo && i < st.hi; (vec.Get(i) == 1) != want {
+					t.Errorf("range [%d, %d): bit %d mismatch", st.lo, st.hi, i)
+				}
+			}
+		}
+		if vec := prepare(10); vec.SetRange(3, 33) || vec.SetRange(5, 4) {
+			t.Error("out of range write")
+		}
+	})
+	t.Run("from uint32", func(t *testing.T) {
+		buf := []uint32{680}
+		vec, err := ConcurrentFromUint32(buf, 32, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chk := map[int]uint8{3: 1, 5: 1, 7: 1, 9: 1}
+		for i := 0; i < 32; i++ {
+			if chk[i] != vec.Get(uint64(i)) {
+				t.Fail()
+			}
+		}
+		vec.Set(31)
+		if buf[0] != 680|1<<31 {
+			t.Error("buffer isn't shared")
+		}
+		if b := bytesOf(vec); len(b) != 4 || b[0] != 168 || b[3] != 128 {
+			t.Errorf("unexpected bytes %v", b)
+		}
+		if _, err = ConcurrentFromUint32(buf, 33, 0); err != ErrShortBuffer {
+			t.Errorf("expected short buffer error, got %v", err)
+		}
+	})
+	t.Run("from uint64", func(t *testing.T) {
+		buf := []uint64{1<<40 | 1<<3}
+		vec, err := ConcurrentFromUint64(buf, 64, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vec.Get(3) != 1 || vec.Get(40) != 1 || vec.Popcnt() != 2 {
+			t.Fail()
+		}
+		vec.Set(63)
+		if buf[0]>>63 != 1 {
+			t.Error("buffer isn't shared")
+		}
+	})
 	t.Run("writer", func(t *testing.T) {
 		vec := prepare(10)
 		f, err := os.OpenFile("testdata/concurrent_vector.bin", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
@@ -120,7 +174,7 @@ func TestConcurrentVector(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		if n != 44 {
+		if n != 56 {
 			t.Fail()
 		}
 	})
@@ -0,0 +1,167 @@
+package bitvector
+
+import (
+	"math/big"
+	"math/bits"
+	"slices"
+)
+
+// FromPositions makes new vector of given kind with bits set at given positions.
+//
+// Positions are expected to be sorted in ascending order, otherwise the vector is still correct, but is built slower.
+// Size of the vector is the greatest position plus one.
+func FromPositions[T uint32 | uint64](pos []T, kind Kind) (Interface, error) {
+	var size uint64
+	for i := 0; i < len(pos); i++ {
+		size = max(size, uint64(pos[i])+1)
+	}
+	vec, err := newOfKind(kind, size, defaultWriteAttemptsLimit)
+	if err != nil {
+		return nil, err
+	}
+	put := orWordsOf(vec)
+	var w, cur uint64
+	for i := 0; i < len(pos); i++ {
+		p := uint64(pos[i])
+		if j := p / 64; j != cur {
+			if w != 0 {
+				put(cur, w)
+			}
+			cur, w = j, 0
+		}
+		w |= 1 << (p % 64)
+	}
+	if w != 0 {
+		put(cur, w)
+	}
+	recount(vec)
+	return vec, nil
+}
+
+// ToPositions appends positions of set bits of the vector to dst in ascending order and returns the extended slice.
+//
+// Positions exceeding T range are truncated, so use uint32 only for vectors with capacity up to 2^32 bits.
+func ToPositions[T uint32 | uint64](dst []T, vec Interface) []T {
+	dst = slices.Grow(dst, int(vec.Popcnt()))
+	it := newWordIter(vec)
+	for i, w, ok := it.next(); ok; i, w, ok = it.next() {
+		for ; w != 0; w &= w - 1 {
+			dst = append(dst, T(i*64+uint64(bits.TrailingZeros64(w))))
+		}
+	}
+	return dst
+}
+
+// FromBools makes new vector of given kind with bits set at positions of true values.
+func FromBools(b []bool, kind Kind) (Interface, error) {
+	vec, err := newOfKind(kind, uint64(len(b)), defaultWriteAttemptsLimit)
+	if err != nil {
+		return nil, err
+	}
+	put := orWordsOf(vec)
+	for i := 0; i < len(b); i += 64 {
+		var w uint64
+		chunk := b[i:min(i+64, len(b))]
+		for j := 0; j < len(chunk); j++ {
+			if chunk[j] {
+				w |= 1 << j
+			}
+		}
+		if w != 0 {
+			put(uint64(i/64), w)
+		}
+	}
+	recount(vec)
+	return vec, nil
+}
+
+// ToBools appends bits of the vector to dst as bool values and returns the extended slice.
+//
+// Dense vectors produce Capacity values, roaring vector produces values up to the last set position.
+func ToBools(dst []bool, vec Interface) []bool {
+	size := bitLen(vec)
+	off := len(dst)
+	dst = slices.Grow(dst, int(size))
+	it := newWordIter(vec)
+	for i, w, ok := it.next(); ok && i*64 < size; i, w, ok = it.next() {
+		dst = appendZeros(dst, int(i*64)-(len(dst)-off))
+		for j, k := uint64(0), min(64, size-i*64); j < k; j++ {
+			dst = append(dst, w&(1<<j) != 0)
+		}
+	}
+	return appendZeros(dst, int(size)-(len(dst)-off))
+}
+
+// FromBigInt makes new vector of given kind from non-negative integer x, where bit at position i has weight 2^i.
+//
+// Size of the vector is the bit length of x (at least one bit).
+func FromBigInt(x *big.Int, kind Kind) (Interface, error) {
+	if x.Sign() < 0 {
+		return nil, ErrNegative
+	}
+	vec, err := newOfKind(kind, max(uint64(x.BitLen()), 1), defaultWriteAttemptsLimit)
+	if err != nil {
+		return nil, err
+	}
+	const k = 64 / bits.UintSize
+	ws := x.Bits()
+	put := orWordsOf(vec)
+	for i := 0; i*k < len(ws); i++ {
+		var w uint64
+		for j := 0; j < k && i*k+j < len(ws); j++ {
+			w |= uint64(ws[i*k+j]) << (j * bits.UintSize)
+		}
+		if w != 0 {
+			put(uint64(i), w)
+		}
+	}
+	recount(vec)
+	return vec, nil
+}
+
+// ToBigInt sets dst to the integer value of the vector, where bit at position i has weight 2^i, and returns dst.
+//
+// If dst is nil new integer will be allocated. Memory of dst is reused if possible.
+func ToBigInt(dst *big.Int, vec Interface) *big.Int {
+	if dst == nil {
+		dst = new(big.Int)
+	}
+	const k = 64 / bits.UintSize
+	ws := dst.Bits()[:0]
+	it := newWordIter(vec)
+	for i, w, ok := it.next(); ok; i, w, ok = it.next() {
+		ws = appendZeros(ws, int(i)*k-len(ws))
+		for j := 0; j < k; j++ {
+			ws = append(ws, big.Word(w>>(j*bits.UintSize)))
+		}
+	}
+	return dst.SetBits(ws)
+}
+
+// FromWords makes new vector of given kind from 64-bit words. Bit j of w[i] becomes bit at position i*64+j.
+func FromWords(w []uint64, kind Kind) (Interface, error) {
+	vec, err := newOfKind(kind, uint64(len(w))*64, defaultWriteAttemptsLimit)
+	if err != nil {
+		return nil, err
+	}
+	put := orWordsOf(vec)
+	for i := 0; i < len(w); i++ {
+		if w[i] != 0 {
+			put(uint64(i), w[i])
+		}
+	}
+	recount(vec)
+	return vec, nil
+}
+
+// ToWords appends 64-bit words of the vector to dst and returns the extended slice. Bit at position i goes to bit i%64
+// of word i/64.
+func ToWords(dst []uint64, vec Interface) []uint64 {
+	it := newWordIter(vec)
+	off := len(dst)
+	dst = slices.Grow(dst, int(it.n))
+	for i, w, ok := it.next(); ok; i, w, ok = it.next() {
+		dst = append(appendZeros(dst, int(i)-(len(dst)-off)), w)
+	}
+	return appendZeros(dst, int(it.n)-(len(dst)-off))
+}
@@ -0,0 +1,114 @@
+package bitvector
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	pos := []uint64{0, 3, 5, 63, 64, 100, 127, 128, 300}
+	kinds := []Kind{KindVector, KindConcurrentVector, KindRoaringVector}
+	for _, kind := range kinds {
+		t.Run(kind.String(), func(t *testing.T) {
+			t.Run("positions", func(t *testing.T) {
+				vec, err := FromPositions(pos, kind)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r := ToPositions[uint64](nil, vec); !slices.Equal(r, pos) {
+					t.Errorf("got %v, want %v", r, pos)
+				}
+				pos32 := []uint32{300, 3, 7}
+				vec, _ = FromPositions(pos32, kind)
+				if r := ToPositions([]uint32{1}, vec); !slices.Equal(r, []uint32{1, 3, 7, 300}) {
+					t.Errorf("unexpected %v", r)
+				}
+			})
+			t.Run("bools", func(t *testing.T) {
+				b := make([]bool, 130)
+				b[1], b[64], b[129] = true, true, true
+				vec, err := FromBools(b, kind)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r := fmt.Sprintf("%v", vec); r != "{1,64,129}" {
+					t.Errorf("unexpected %s", r)
+				}
+				r := ToBools(nil, vec)
+				if len(r) < len(b) || !slices.Equal(r[:len(b)], b) || slices.Contains(r[len(b):], true) {
+					t.Errorf("bools mismatch")
+				}
+			})
+			t.Run("big int", func(t *testing.T) {
+				x, _ := new(big.Int).SetString("123456789abcdef0123456789abcdef", 16)
+				vec, err := FromBigInt(x, kind)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r := ToBigInt(nil, vec); r.Cmp(x) != 0 {
+					t.Errorf("got %x, want %x", r, x)
+				}
+				if _, err = FromBigInt(big.NewInt(-1), kind); err != ErrNegative {
+					t.Errorf("expected negative error, got %v", err)
+				}
+			})
+			t.Run("words", func(t *testing.T) {
+				w := []uint64{0xf0, 0, 1 << 63}
+				vec, err := FromWords(w, kind)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Dense vectors may have trailing zero words due to capacity rounding.
+				r := ToWords(nil, vec)
+				for len(r) > len(w) && r[len(r)-1] == 0 {
+					r = r[:len(r)-1]
+				}
+				if !slices.Equal(r, w) {
+					t.Errorf("got %x, want %x", r, w)
+				}
+				if kind != KindRoaringVector && vec.Size() != 5 {
+					t.Errorf("size mismatch: %d", vec.Size())
+				}
+			})
+		})
+	}
+	t.Run("sparse roaring", func(t *testing.T) {
+		// Words are iterated over containers, so huge positions don't take time.
+		pos := []uint64{5, 1 << 36, 1<<40 + 1, 1<<40 + 64}
+		vec, _ := FromPositions(pos, KindRoaringVector)
+		if r := ToPositions[uint64](nil, vec); !slices.Equal(r, pos) {
+			t.Errorf("got %v, want %v", r, pos)
+		}
+		vec1, _ := FromPositions(pos[:3], KindRoaringVector)
+		if Compare(vec1, vec) != -1 || CompareNumeric(vec1, vec) != -1 {
+			t.Error("unexpected comparison result")
+		}
+		p := Diff(vec1, vec)
+		if err := Apply(vec1, p); err != nil || Compare(vec1, vec) != 0 {
+			t.Errorf("got %v, error %v", vec1, err)
+		}
+	})
+}
+
+func BenchmarkConvert(b *testing.B) {
+	vec, _ := NewVector(1e5)
+	for i := uint64(0); i < 1e5; i += 3 {
+		vec.Set(i)
+	}
+	b.Run("to positions", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []uint64
+		for i := 0; i < b.N; i++ {
+			buf = ToPositions(buf[:0], vec)
+		}
+	})
+	b.Run("to words", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []uint64
+		for i := 0; i < b.N; i++ {
+			buf = ToWords(buf[:0], vec)
+		}
+	})
+}
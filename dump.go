@@ -0,0 +1,165 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"slices"
+	"unsafe"
+)
+
+const (
+	// Dump header flag common for all vector types: the dump ends with CRC32C of all preceding bytes.
+	dumpFlagChecksum = 1 << 1
+
+	// Payload is read by chunks of this size, thus a corrupted length in header can't cause allocation of memory
+	// which stream doesn't actually contain.
+	dumpChunkSz = 1 << 20
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// crcWriter calculates CRC32C and counts all written data.
+type crcWriter struct {
+	w   io.Writer
+	crc uint32
+	n   int64
+}
+
+func (w *crcWriter) Write(p []byte) (n int, err error) {
+	n, err = w.w.Write(p)
+	w.crc = crc32.Update(w.crc, crcTable, p[:n])
+	w.n += int64(n)
+	return
+}
+
+// writeChecksum writes checksum of all data written so far.
+func (w *crcWriter) writeChecksum() error {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], w.crc)
+	n, err := w.w.Write(buf[:])
+	w.n += int64(n)
+	return err
+}
+
+// crcReader calculates CRC32C and counts all read data.
+type crcReader struct {
+	r   io.Reader
+	crc uint32
+	n   int64
+}
+
+func (r *crcReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	r.crc = crc32.Update(r.crc, crcTable, p[:n])
+	r.n += int64(n)
+	return
+}
+
+// readChecksum reads the checksum trailer and compares it with checksum of all data read so far.
+func (r *crcReader) readChecksum() error {
+	var buf [4]byte
+	n, err := readFull(r.r, buf[:])
+	r.n += int64(n)
+	if err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint32(buf[:]) != r.crc {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// payloadWriter returns writer of dump payload according to dump flags and the function completing the payload.
+func payloadWriter(w io.Writer, flags uint64) (io.Writer, func() error) {
+	var (
+		dones []func() error
+		done  func() error
+	)
+	if flags&dumpFlagCompressed != 0 {
+		w, done = compressWriter(w)
+		dones = append(dones, done)
+	}
+	if flags&dumpFlagBlocks != 0 {
+		e := &blockEncoder{w: w}
+		w = e
+		dones = append(dones, e.flush)
+	}
+	return w, func() error {
+		// Complete from the outermost writer.
+		for i := len(dones) - 1; i >= 0; i-- {
+			if err := dones[i](); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// payloadReader returns reader of dump payload according to dump flags and the function completing the payload.
+//
+// Completion makes sure the whole payload is consumed, so the checksum trailer may be read next. Size is the raw
+// payload size promised by the header, it bounds decompressed data.
+func payloadReader(r io.Reader, flags, size uint64) (io.Reader, func() error) {
+	var (
+		dones []func() error
+		done  func() error
+	)
+	if flags&dumpFlagCompressed != 0 {
+		r, done = compressReader(r, encodedLimit(size, flags))
+		dones = append(dones, done)
+	}
+	if flags&dumpFlagBlocks != 0 {
+		d := &blockDecoder{r: r}
+		r = d
+		dones = append(dones, d.done)
+	}
+	return r, func() error {
+		for i := len(dones) - 1; i >= 0; i-- {
+			if err := dones[i](); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// readFull reads exactly len(p) bytes. Unlike io.ReadFull any EOF is reported as ErrTruncated, since the function reads
+// data that the header promises to exist.
+func readFull(r io.Reader, p []byte) (int, error) {
+	n, err := io.ReadFull(r, p)
+	if err == io.EOF {
+		err = ErrTruncated
+	}
+	return n, truncated(err)
+}
+
+// readSlice reads n items of T to dst reusing its capacity and returns the resulting slice.
+//
+// Items are stored in little-endian order. Slice grows by chunks while the data arrives, so huge n can't cause huge
+// allocation. On error dst returns with unchanged length, but its contents may be overwritten.
+func readSlice[T uint8 | uint32 | uint64](r io.Reader, dst []T, n uint64) ([]T, error) {
+	sz := uint64(unsafe.Sizeof(T(0)))
+	buf := dst[:0]
+	for uint64(len(buf)) < n {
+		k := int(min(n-uint64(len(buf)), dumpChunkSz/sz))
+		off := len(buf)
+		buf = slices.Grow(buf, k)[:off+k]
+		p := unsafe.Slice((*byte)(unsafe.Pointer(&buf[off])), k*int(sz))
+		if _, err := readFull(r, p); err != nil {
+			return dst, err
+		}
+		if !hostLE {
+			decodeLE(buf[off:], p)
+		}
+	}
+	return buf, nil
+}
+
+// truncated converts unexpected EOF to ErrTruncated.
+func truncated(err error) error {
+	if err == io.ErrUnexpectedEOF {
+		return ErrTruncated
+	}
+	return err
+}
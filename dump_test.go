@@ -0,0 +1,173 @@
+package bitvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDump(t *testing.T) {
+	const pos = "3,5,7-9,100,5000"
+	kinds := []Kind{KindVector, KindConcurrentVector, KindRoaringVector}
+	dump := func(vec Interface) []byte {
+		var buf bytes.Buffer
+		_, _ = vec.WriteTo(&buf)
+		return buf.Bytes()
+	}
+	t.Run("short reads", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse(pos, kind)
+			p := dump(vec)
+			vec1, _ := newOfKind(kind, 1, 0)
+			n, err := vec1.ReadFrom(iotest.OneByteReader(bytes.NewReader(p)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(p)) || Compare(vec, vec1) != 0 {
+				t.Errorf("%s: read %d of %d bytes, got %v", kind, n, len(p), vec1)
+			}
+		}
+	})
+	t.Run("reuse buffer", func(t *testing.T) {
+		for _, kind := range []Kind{KindVector, KindConcurrentVector} {
+			vec, _ := Parse("3,5", kind)
+			vec1, _ := newOfKind(kind, 10000, 0)
+			setRange(vec1, 0, 10000)
+			if _, err := vec1.ReadFrom(bytes.NewReader(dump(vec))); err != nil {
+				t.Fatal(err)
+			}
+			if Compare(vec, vec1) != 0 || vec1.Popcnt() != 2 {
+				t.Errorf("%s: got %v", kind, vec1)
+			}
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse(pos, kind)
+			p := dump(vec)
+			for i := 1; i < len(p); i++ {
+				vec1, _ := newOfKind(kind, 1, 0)
+				if _, err := vec1.ReadFrom(bytes.NewReader(p[:i])); err != ErrTruncated {
+					t.Errorf("%s of %d bytes: expected truncated error, got %v", kind, i, err)
+				}
+			}
+		}
+	})
+	t.Run("checksum", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse(pos, kind)
+			p := dump(vec)
+			p[len(p)-5] ^= 1
+			vec1, _ := newOfKind(kind, 1, 0)
+			if _, err := vec1.ReadFrom(bytes.NewReader(p)); err != ErrChecksumMismatch {
+				t.Errorf("%s: expected checksum mismatch error, got %v", kind, err)
+			}
+		}
+	})
+	t.Run("checksum target", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse(pos, kind)
+			p := dump(vec)
+			p[len(p)-5] ^= 1
+			for _, size := range []uint64{1, sizeOf(vec)} {
+				vec1, _ := newOfKind(kind, size, 0)
+				vec1.Set(0)
+				orig, n := vec1.Clone(), sizeOf(vec1)
+				if _, err := vec1.ReadFrom(bytes.NewReader(p)); err != ErrChecksumMismatch {
+					t.Errorf("%s: expected checksum mismatch error, got %v", kind, err)
+				}
+				if sizeOf(vec1) != n || Compare(vec1, orig) != 0 && (vec1.Popcnt() != 0 || vec1.Size() != 0) {
+					t.Errorf("%s of size %d: vector must stay unchanged or empty after failed read", kind, size)
+				}
+			}
+		}
+	})
+	t.Run("huge length", func(t *testing.T) {
+		var p []byte
+		p = binary.LittleEndian.AppendUint64(p, vectorDumpSignature)
+		p = binary.LittleEndian.AppendUint64(p, DumpVersion)
+		p = binary.LittleEndian.AppendUint64(p, 1<<62)
+		p = binary.LittleEndian.AppendUint64(p, 0)
+		p = binary.LittleEndian.AppendUint64(p, 0)
+		p = append(p, make([]byte, 64)...)
+		vec, _ := NewVector(1)
+		if _, err := vec.ReadFrom(bytes.NewReader(p)); err != ErrTruncated {
+			t.Errorf("expected truncated error, got %v", err)
+		}
+	})
+	t.Run("size counter", func(t *testing.T) {
+		// Size is a counter of writes, unset of clear bit makes it greater than capacity.
+		for _, kind := range []Kind{KindVector, KindConcurrentVector} {
+			vec, _ := newOfKind(kind, 10, 0)
+			vec.Unset(3)
+			p := dump(vec)
+			if h, err := Inspect(bytes.NewReader(p)); err != nil || h.Size != vec.Size() {
+				t.Errorf("%s: got header %+v, error %v", kind, h, err)
+			}
+			vec1, err := Load(bytes.NewReader(p))
+			if err != nil {
+				t.Fatalf("%s: %v", kind, err)
+			}
+			if vec1.Size() != vec.Size() || vec1.Popcnt() != 0 {
+				t.Errorf("%s: got %v of size %d", kind, vec1, vec1.Size())
+			}
+		}
+	})
+	t.Run("concurrent reader v1", func(t *testing.T) {
+		var p []byte
+		p = binary.LittleEndian.AppendUint64(p, cnVectorDumpSignature)
+		p = binary.LittleEndian.AppendUint64(p, math.Float64bits(cnVectorDumpVersion1))
+		p = binary.LittleEndian.AppendUint64(p, 10)
+		p = binary.LittleEndian.AppendUint64(p, 4)
+		p = binary.LittleEndian.AppendUint64(p, 1)
+		p = binary.LittleEndian.AppendUint32(p, 680)
+		vec, _ := NewConcurrentVector(1, 0)
+		if _, err := vec.ReadFrom(bytes.NewReader(p)); err != nil {
+			t.Fatal(err)
+		}
+		if vec.Popcnt() != 4 || vec.Get(9) != 1 {
+			t.Errorf("got %v", vec)
+		}
+	})
+}
+
+// fuzzReadFrom checks that arbitrary input doesn't break ReadFrom and that successfully read vector is dumped stably.
+func fuzzReadFrom(f *testing.F, kind Kind) {
+	for _, s := range []string{"3,5,7-9,100", "0-200", "1000000"} {
+		vec, _ := Parse(s, kind)
+		_ = SetMetadata(vec, Metadata{"src": s})
+		var buf, zbuf, bbuf bytes.Buffer
+		_, _ = vec.WriteTo(&buf)
+		_, _ = WriteCompressed(&zbuf, vec)
+		_, _ = WriteAdaptive(&bbuf, vec)
+		f.Add(buf.Bytes())
+		f.Add(zbuf.Bytes())
+		f.Add(bbuf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, p []byte) {
+		vec, _ := newOfKind(kind, 1, 0)
+		if _, err := vec.ReadFrom(bytes.NewReader(p)); err != nil {
+			return
+		}
+		var buf, buf1 bytes.Buffer
+		if _, err := vec.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		vec1, _ := newOfKind(kind, 1, 0)
+		if _, err := vec1.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := vec1.WriteTo(&buf1); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), buf1.Bytes()) {
+			t.Error("dump mismatch")
+		}
+	})
+}
+
+func FuzzVectorReadFrom(f *testing.F)           { fuzzReadFrom(f, KindVector) }
+func FuzzConcurrentVectorReadFrom(f *testing.F) { fuzzReadFrom(f, KindConcurrentVector) }
+func FuzzRoaringVectorReadFrom(f *testing.F)    { fuzzReadFrom(f, KindRoaringVector) }
@@ -0,0 +1,217 @@
+package bitvector
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*vector)(nil)
+	_ encoding.BinaryUnmarshaler = (*vector)(nil)
+	_ encoding.TextMarshaler     = (*vector)(nil)
+	_ encoding.TextUnmarshaler   = (*vector)(nil)
+	_ json.Marshaler             = (*vector)(nil)
+	_ json.Unmarshaler           = (*vector)(nil)
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler. Result is a binary dump, see WriteTo.
+func (vec *vector) MarshalBinary() ([]byte, error) {
+	return marshalBinary(vec)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (vec *vector) UnmarshalBinary(p []byte) error {
+	return unmarshalBinary(vec, p)
+}
+
+// MarshalText implements encoding.TextMarshaler. Result is a base64 encoded binary dump.
+func (vec *vector) MarshalText() ([]byte, error) {
+	return marshalText(vec)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (vec *vector) UnmarshalText(p []byte) error {
+	return unmarshalText(vec, p)
+}
+
+// MarshalJSON implements json.Marshaler. Vector is encoded as string with base64 encoded binary dump, use
+// JSONPositions to encode it as array of positions.
+func (vec *vector) MarshalJSON() ([]byte, error) {
+	return marshalJSON(vec)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Both base64 string and array of positions are accepted.
+func (vec *vector) UnmarshalJSON(p []byte) error {
+	return unmarshalJSON(vec, p)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. Result is a binary dump, see WriteTo.
+func (vec *concurrentVector) MarshalBinary() ([]byte, error) {
+	return marshalBinary(vec)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (vec *concurrentVector) UnmarshalBinary(p []byte) error {
+	return unmarshalBinary(vec, p)
+}
+
+// MarshalText implements encoding.TextMarshaler. Result is a base64 encoded binary dump.
+func (vec *concurrentVector) MarshalText() ([]byte, error) {
+	return marshalText(vec)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (vec *concurrentVector) UnmarshalText(p []byte) error {
+	return unmarshalText(vec, p)
+}
+
+// MarshalJSON implements json.Marshaler. Vector is encoded as string with base64 encoded binary dump, use
+// JSONPositions to encode it as array of positions.
+func (vec *concurrentVector) MarshalJSON() ([]byte, error) {
+	return marshalJSON(vec)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Both base64 string and array of positions are accepted.
+func (vec *concurrentVector) UnmarshalJSON(p []byte) error {
+	return unmarshalJSON(vec, p)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. Result is a binary dump of the source concurrent vector.
+func (vec *frozenVector) MarshalBinary() ([]byte, error) {
+	return marshalBinary(vec)
+}
+
+// MarshalText implements encoding.TextMarshaler. Result is a base64 encoded binary dump of the source concurrent
+// vector.
+func (vec *frozenVector) MarshalText() ([]byte, error) {
+	return marshalText(vec)
+}
+
+// MarshalJSON implements json.Marshaler. Vector is encoded as string with base64 encoded binary dump of the source
+// concurrent vector.
+func (vec *frozenVector) MarshalJSON() ([]byte, error) {
+	return marshalJSON(vec)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. Result is a binary dump, see WriteTo.
+func (vec *roaringVector) MarshalBinary() ([]byte, error) {
+	return marshalBinary(vec)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (vec *roaringVector) UnmarshalBinary(p []byte) error {
+	return unmarshalBinary(vec, p)
+}
+
+// MarshalText implements encoding.TextMarshaler. Result is a base64 encoded binary dump.
+func (vec *roaringVector) MarshalText() ([]byte, error) {
+	return marshalText(vec)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (vec *roaringVector) UnmarshalText(p []byte) error {
+	return unmarshalText(vec, p)
+}
+
+// MarshalJSON implements json.Marshaler. Vector is encoded as string with base64 encoded binary dump, use
+// JSONPositions to encode it as array of positions.
+func (vec *roaringVector) MarshalJSON() ([]byte, error) {
+	return marshalJSON(vec)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Both base64 string and array of positions are accepted.
+func (vec *roaringVector) UnmarshalJSON(p []byte) error {
+	return unmarshalJSON(vec, p)
+}
+
+// JSONPositions wraps the vector to encode it to JSON as array of set positions, e.g. [3,5,7].
+type JSONPositions struct {
+	Vec Interface
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v JSONPositions) MarshalJSON() ([]byte, error) {
+	if v.Vec == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(ToPositions[uint64](make([]uint64, 0), v.Vec))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Vec must be initialized, it's reset and filled with positions.
+func (v JSONPositions) UnmarshalJSON(p []byte) error {
+	if v.Vec == nil {
+		return ErrWrongType
+	}
+	return unmarshalJSON(v.Vec, p)
+}
+
+func marshalBinary(v Interface) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := v.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unmarshalBinary(v Interface, p []byte) error {
+	_, err := v.ReadFrom(bytes.NewReader(p))
+	return err
+}
+
+func marshalText(v Interface) ([]byte, error) {
+	p, err := marshalBinary(v)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, base64.StdEncoding.EncodedLen(len(p)))
+	base64.StdEncoding.Encode(buf, p)
+	return buf, nil
+}
+
+func unmarshalText(v Interface, p []byte) error {
+	buf := make([]byte, base64.StdEncoding.DecodedLen(len(p)))
+	n, err := base64.StdEncoding.Decode(buf, p)
+	if err != nil {
+		return err
+	}
+	return unmarshalBinary(v, buf[:n])
+}
+
+func marshalJSON(v Interface) ([]byte, error) {
+	p, err := marshalText(v)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, len(p)+2)
+	buf = append(buf, '"')
+	buf = append(buf, p...)
+	return append(buf, '"'), nil
+}
+
+func unmarshalJSON(v Interface, p []byte) error {
+	p = bytes.TrimSpace(p)
+	switch {
+	case bytes.Equal(p, []byte("null")):
+		return nil
+	case len(p) > 0 && p[0] == '[':
+		var pos []uint64
+		if err := json.Unmarshal(p, &pos); err != nil {
+			return err
+		}
+		v.Reset()
+		for _, x := range pos {
+			if !v.Set(x) {
+				return ErrShortBuffer
+			}
+		}
+		return nil
+	default:
+		var s string
+		if err := json.Unmarshal(p, &s); err != nil {
+			return err
+		}
+		return unmarshalText(v, []byte(s))
+	}
+}
@@ -0,0 +1,102 @@
+package bitvector
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
+
+func TestEncoding(t *testing.T) {
+	const pos = "3,5,7-9,100"
+	kinds := []Kind{KindVector, KindConcurrentVector, KindRoaringVector}
+	t.Run("binary", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse(pos, kind)
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(vec); err != nil {
+				t.Fatal(err)
+			}
+			vec1, _ := newOfKind(kind, 1, 0)
+			if err := gob.NewDecoder(&buf).Decode(vec1); err != nil {
+				t.Fatal(err)
+			}
+			if Compare(vec, vec1) != 0 {
+				t.Errorf("%s: got %v, want %v", kind, vec1, vec)
+			}
+		}
+	})
+	t.Run("text", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse(pos, kind)
+			p, err := vec.(interface{ MarshalText() ([]byte, error) }).MarshalText()
+			if err != nil {
+				t.Fatal(err)
+			}
+			vec1, _ := newOfKind(kind, 1, 0)
+			if err = vec1.(interface{ UnmarshalText([]byte) error }).UnmarshalText(p); err != nil {
+				t.Fatal(err)
+			}
+			if Compare(vec, vec1) != 0 {
+				t.Errorf("%s: got %v, want %v", kind, vec1, vec)
+			}
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		type doc struct {
+			Vec Interface `json:"vec"`
+		}
+		for _, kind := range kinds {
+			vec, _ := Parse(pos, kind)
+			p, err := json.Marshal(doc{Vec: vec})
+			if err != nil {
+				t.Fatal(err)
+			}
+			vec1, _ := newOfKind(kind, 1, 0)
+			if err = json.Unmarshal(p, &doc{Vec: vec1}); err != nil {
+				t.Fatal(err)
+			}
+			if Compare(vec, vec1) != 0 {
+				t.Errorf("%s: got %v, want %v", kind, vec1, vec)
+			}
+		}
+	})
+	t.Run("json positions", func(t *testing.T) {
+		vec, _ := Parse(pos, KindVector)
+		p, err := json.Marshal(JSONPositions{Vec: vec})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(p) != "[3,5,7,8,9,100]" {
+			t.Errorf("unexpected JSON %s", p)
+		}
+		for _, kind := range kinds {
+			vec1, _ := newOfKind(kind, 128, 0)
+			vec1.Set(1)
+			if err = json.Unmarshal(p, vec1); err != nil {
+				t.Fatal(err)
+			}
+			if Compare(vec, vec1) != 0 {
+				t.Errorf("%s: got %v, want %v", kind, vec1, vec)
+			}
+		}
+		vec1, _ := NewVector(64)
+		if err = json.Unmarshal(p, vec1); err != ErrShortBuffer {
+			t.Errorf("expected short buffer error, got %v", err)
+		}
+	})
+	t.Run("frozen", func(t *testing.T) {
+		vec, _ := Parse(pos, KindConcurrentVector)
+		p, err := json.Marshal(vec.(Freezer).Freeze())
+		if err != nil {
+			t.Fatal(err)
+		}
+		vec1, _ := NewConcurrentVector(1, 0)
+		if err = json.Unmarshal(p, vec1); err != nil {
+			t.Fatal(err)
+		}
+		if Compare(vec, vec1) != 0 {
+			t.Errorf("got %v, want %v", vec1, vec)
+		}
+	})
+}
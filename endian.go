@@ -0,0 +1,78 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"io"
+	"unsafe"
+)
+
+// hostLE indicates little-endian host.
+//
+// Dumps are little-endian, so on such hosts integer slices are written and read as raw memory, otherwise the portable
+// encoding loop is used. Tests reset the flag to check the portable path.
+var hostLE = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// writeSlice writes items of s to w in little-endian order.
+func writeSlice[T uint32 | uint64](w io.Writer, s []T) (n int, err error) {
+	if len(s) == 0 {
+		return
+	}
+	sz := int(unsafe.Sizeof(s[0]))
+	if hostLE {
+		return w.Write(unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*sz))
+	}
+	var (
+		buf [4096]byte
+		m   int
+	)
+	for len(s) > 0 {
+		k := min(len(s), len(buf)/sz)
+		encodeLE(buf[:k*sz], s[:k])
+		m, err = w.Write(buf[:k*sz])
+		n += m
+		if err != nil {
+			return
+		}
+		s = s[k:]
+	}
+	return
+}
+
+// encodeLE puts items of s to dst in little-endian order.
+func encodeLE[T uint8 | uint32 | uint64](dst []byte, s []T) {
+	switch unsafe.Sizeof(T(0)) {
+	case 1:
+		for i := 0; i < len(s); i++ {
+			dst[i] = uint8(s[i])
+		}
+	case 4:
+		for i := 0; i < len(s); i++ {
+			binary.LittleEndian.PutUint32(dst[i*4:], uint32(s[i]))
+		}
+	case 8:
+		for i := 0; i < len(s); i++ {
+			binary.LittleEndian.PutUint64(dst[i*8:], uint64(s[i]))
+		}
+	}
+}
+
+// decodeLE fills dst with little-endian items from p. Both may share the same memory.
+func decodeLE[T uint8 | uint32 | uint64](dst []T, p []byte) {
+	switch unsafe.Sizeof(T(0)) {
+	case 1:
+		for i := 0; i < len(dst); i++ {
+			dst[i] = T(p[i])
+		}
+	case 4:
+		for i := 0; i < len(dst); i++ {
+			dst[i] = T(binary.LittleEndian.Uint32(p[i*4:]))
+		}
+	case 8:
+		for i := 0; i < len(dst); i++ {
+			dst[i] = T(binary.LittleEndian.Uint64(p[i*8:]))
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package bitvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestEndian(t *testing.T) {
+	// portable switches to the encoding loop used on big-endian hosts.
+	portable := func(t *testing.T) {
+		le := hostLE
+		hostLE = false
+		t.Cleanup(func() { hostLE = le })
+	}
+	dump := func(vec Interface) []byte {
+		var buf bytes.Buffer
+		_, _ = vec.WriteTo(&buf)
+		return buf.Bytes()
+	}
+	t.Run("portable dump", func(t *testing.T) {
+		vecs := make([]Interface, 0, 3)
+		fast := make([][]byte, 0, 3)
+		for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+			pos := "3,5,7-9,100"
+			if kind == KindRoaringVector {
+				pos += ",4294967301"
+			}
+			vec, _ := Parse(pos, kind)
+			vecs = append(vecs, vec)
+			fast = append(fast, dump(vec))
+		}
+		portable(t)
+		for i, vec := range vecs {
+			p := dump(vec)
+			if !bytes.Equal(p, fast[i]) {
+				t.Errorf("%s: portable dump differs", kindOf(vec))
+			}
+			vec1, _ := newOfKind(kindOf(vec), 1, 0)
+			if _, err := vec1.ReadFrom(bytes.NewReader(p)); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(dump(vec1), p) {
+				t.Errorf("%s: got %v, want %v", kindOf(vec), vec1, vec)
+			}
+		}
+	})
+	t.Run("roaring layout", func(t *testing.T) {
+		portable(t)
+		vec, _ := Parse("4294967301", KindRoaringVector)
+		p := dump(vec)
+		// Header, keys, number of bitmaps, bitmap header and its positions.
+		var want []byte
+		want = binary.LittleEndian.AppendUint32(want, 1)
+		want = binary.LittleEndian.AppendUint64(want, 1)
+		if !bytes.Equal(p[32:44], want) {
+			t.Errorf("unexpected keys layout % x", p[32:44])
+		}
+		if got := binary.LittleEndian.Uint32(p[60:64]); got != 5 {
+			t.Errorf("unexpected bitmap layout % x", p[44:64])
+		}
+	})
+	t.Run("concurrent bytes", func(t *testing.T) {
+		w := uint64(1<<40 | 1<<3)
+		want := binary.LittleEndian.AppendUint64(nil, w)
+		portable(t)
+		for _, le := range []bool{true, false} {
+			hostLE = le
+			vec, _ := ConcurrentFromUint64([]uint64{w}, 64, 0)
+			if vec.Get(3) != 1 || vec.Get(40) != 1 || vec.Popcnt() != 2 {
+				t.Errorf("host LE %t: got %v", le, vec)
+			}
+			if vec.(*concurrentVector).borrowed != le {
+				t.Errorf("host LE %t: buffer must be borrowed on little-endian hosts only", le)
+			}
+			if b := bytesOf(vec); !bytes.Equal(b, want) {
+				t.Errorf("host LE %t: got bytes % x", le, b)
+			}
+			if b := ExportBytes(nil, vec, LSBFirst); !bytes.Equal(b, want) {
+				t.Errorf("host LE %t: got exported bytes % x", le, b)
+			}
+			if frz := vec.(Freezer).Freeze(); frz.Get(40) != 1 || !bytes.Equal(bytesOf(frz), want) {
+				t.Errorf("host LE %t: got frozen %v", le, frz)
+			}
+		}
+	})
+	t.Run("slices", func(t *testing.T) {
+		s32 := []uint32{0x01020304, 0xa0b0c0d0}
+		s64 := []uint64{0x0102030405060708}
+		portable(t)
+		for _, le := range []bool{true, false} {
+			hostLE = le
+			var buf bytes.Buffer
+			_, _ = writeSlice(&buf, s32)
+			_, _ = writeSlice(&buf, s64)
+			want := []byte{4, 3, 2, 1, 0xd0, 0xc0, 0xb0, 0xa0, 8, 7, 6, 5, 4, 3, 2, 1}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("host LE %t: got % x", le, buf.Bytes())
+			}
+			r32, _ := readSlice[uint32](&buf, nil, 2)
+			r64, _ := readSlice[uint64](&buf, nil, 1)
+			if r32[0] != s32[0] || r32[1] != s32[1] || r64[0] != s64[0] {
+				t.Errorf("host LE %t: got %x %x", le, r32, r64)
+			}
+		}
+	})
+}
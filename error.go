@@ -8,4 +8,17 @@ var (
 	ErrVersionMismatch  = errors.New("vector version mismatch")
 	ErrNotEqualSize     = errors.New("vectors must have equal size")
 	ErrWrongType        = errors.New("wrong type provided")
+	ErrShortBuffer      = errors.New("buffer is too short for given size")
+	ErrFrozen           = errors.New("vector is frozen")
+	ErrNegative         = errors.New("negative value provided")
+	ErrBitOrderMismatch = errors.New("vectors must have equal bit order")
+	ErrUnknownFlags     = errors.New("unknown dump flags")
+	ErrTruncated        = errors.New("vector dump is truncated")
+	ErrChecksumMismatch = errors.New("vector dump checksum mismatch")
+	ErrCorrupted        = errors.New("vector dump is corrupted")
+	ErrMetadataTooLarge = errors.New("metadata is too large")
+	ErrNotMappable      = errors.New("dump can't be memory mapped")
+	ErrPatchMismatch    = errors.New("patch base mismatch")
+	ErrWriteFailed      = errors.New("vector write failed")
+	ErrFileBacked       = errors.New("vector is backed by file")
 )
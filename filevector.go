@@ -0,0 +1,142 @@
+package bitvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	// Offsets of size and flags fields in the vector dump header.
+	fileSizeOffset  = 24
+	fileFlagsOffset = 32
+)
+
+// VectorFile keeps the file of file-backed vector, see OpenFileVector.
+type VectorFile struct {
+	f   *os.File
+	p   []byte
+	vec *vector
+}
+
+// OpenFileVector opens or creates the file at path and returns mutable vector stored in it.
+//
+// The file is a plain dump of vector (see WriteTo) mapped to memory, so Set, Unset and other modifications update the
+// file directly without copying. New file is created with given size, existing one must have equal capacity and must
+// be neither compressed nor block encoded dump of the current version, otherwise ErrNotEqualSize, ErrNotMappable or
+// ErrVersionMismatch returns. Checksum of existing dump is dropped, since it can't be maintained on every write.
+//
+// Size of the vector is reset to its population count and stored to the header by Sync and Close, use Sync to make
+// the modifications durable. Metadata is read on opening and its further changes aren't stored. The vector must not be
+// used after Close and must not be put to Pool. ReadFrom of the vector fails with ErrFileBacked, since the dump may
+// differ in capacity and bit order from the file.
+func OpenFileVector(path string, size uint64) (Interface, *VectorFile, error) {
+	if size == 0 {
+		return nil, nil, ErrZeroSize
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	vf, err := openVectorFile(f, size)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+	return vf.vec, vf, nil
+}
+
+func openVectorFile(f *os.File, size uint64) (*VectorFile, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	fsize := fi.Size()
+	if fsize == 0 {
+		var buf bytes.Buffer
+		_ = writeHeader(&buf, vectorDumpSignature, 0, nil, size, 0)
+		if _, err = f.Write(buf.Bytes()); err != nil {
+			return nil, err
+		}
+		fsize = int64(buf.Len()) + int64(size/8+1)
+		if err = f.Truncate(fsize); err != nil {
+			return nil, err
+		}
+	}
+	h, err := readHeader(io.NewSectionReader(f, 0, fsize), vectorDumpSignature)
+	if err != nil {
+		return nil, err
+	}
+	c, s, flags := h.fields[0], h.fields[1], h.flags
+	if h.ver != DumpVersion {
+		return nil, ErrVersionMismatch
+	}
+	if flags&(dumpFlagCompressed|dumpFlagBlocks) != 0 {
+		return nil, ErrNotMappable
+	}
+	if flags&^(vectorFlagMSB|dumpFlagChecksum|dumpFlagMetadata) != 0 {
+		return nil, ErrUnknownFlags
+	}
+	if c != size {
+		return nil, ErrNotEqualSize
+	}
+	need := uint64(h.len) + c/8 + 1
+	if need > math.MaxInt {
+		return nil, ErrNotMappable
+	}
+	if uint64(fsize) < need {
+		return nil, ErrTruncated
+	}
+	if flags&dumpFlagChecksum != 0 {
+		// Clear the flag first, so the file stays readable if truncation fails.
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], flags&^dumpFlagChecksum)
+		if _, err = f.WriteAt(buf[:], fileFlagsOffset); err != nil {
+			return nil, err
+		}
+		if err = f.Truncate(int64(need)); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := mapFile(f, int(need))
+	if err != nil {
+		return nil, err
+	}
+	vec := &vector{buf: p[h.len:need:need], c: c, s: s, borrowed: true, mapped: true, md: h.md}
+	if flags&vectorFlagMSB != 0 {
+		vec.o = MSBFirst.mask()
+	}
+	return &VectorFile{f: f, p: p, vec: vec}, nil
+}
+
+// Sync writes size of the vector to the header and flushes the file to stable storage.
+//
+// Size is a counter of writes, which Unset of clear bit makes inconsistent, so population count is stored instead.
+func (vf *VectorFile) Sync() error {
+	if vf.p == nil {
+		return os.ErrClosed
+	}
+	vf.vec.s = vf.vec.Popcnt()
+	binary.LittleEndian.PutUint64(vf.p[fileSizeOffset:], vf.vec.s)
+	return syncFile(vf.f, vf.p)
+}
+
+// Close syncs and closes the file. The vector becomes empty and read-only.
+func (vf *VectorFile) Close() error {
+	if vf.p == nil {
+		return nil
+	}
+	err := vf.Sync()
+	if err1 := unmapFile(vf.p); err == nil {
+		err = err1
+	}
+	if err1 := vf.f.Close(); err == nil {
+		err = err1
+	}
+	vf.p = nil
+	vf.vec.buf, vf.vec.ro = nil, true
+	return err
+}
@@ -0,0 +1,173 @@
+package bitvector
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpenFileVector(t *testing.T) {
+	load := func(t *testing.T, path string) Interface {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = f.Close() }()
+		vec, err := Load(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vec
+	}
+	t.Run("create", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "vector.bin")
+		vec, vf, err := OpenFileVector(path, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vec.Set(3)
+		vec.Set(5)
+		setRange(vec, 100, 200)
+		vec.Unset(150)
+		if err = vf.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		// Synced file is a valid dump.
+		want, _ := NewVector(1000)
+		want.Set(3)
+		want.Set(5)
+		setRange(want, 100, 200)
+		want.Unset(150)
+		if vec1 := load(t, path); Compare(want, vec1) != 0 || vec1.Size() != vec.Size() {
+			t.Errorf("got %v, want %v", vec1, want)
+		}
+		vec.Set(999)
+		if err = vf.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if vec.Set(1) || vec.Get(3) != 0 {
+			t.Error("closed vector must be empty and read-only")
+		}
+
+		vec, vf, err = OpenFileVector(path, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = vf.Close() }()
+		want.Set(999)
+		if Compare(want, vec) != 0 || vec.Size() != 102 {
+			t.Errorf("got %v with size %d after reopening", vec, vec.Size())
+		}
+	})
+	t.Run("existing", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "vector.bin")
+		src, _ := NewVectorWithOrder(64, MSBFirst)
+		src.Set(1)
+		_ = SetMetadata(src, Metadata{"job": "daily"})
+		f, _ := os.Create(path)
+		if _, err := src.WriteTo(f); err != nil {
+			t.Fatal(err)
+		}
+		_ = f.Close()
+
+		vec, vf, err := OpenFileVector(path, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vec.Get(1) != 1 || bytesOf(vec)[0] != 0x40 || !reflect.DeepEqual(MetadataOf(vec), Metadata{"job": "daily"}) {
+			t.Errorf("unexpected vector %v", vec)
+		}
+		vec.Set(2)
+		if err = vf.Close(); err != nil {
+			t.Fatal(err)
+		}
+		vec1 := load(t, path)
+		if vec1.Get(2) != 1 || vec1.Size() != 2 || !reflect.DeepEqual(MetadataOf(vec1), Metadata{"job": "daily"}) {
+			t.Errorf("got %v", vec1)
+		}
+		h, _ := Inspect(must(os.Open(path)))
+		if h.Flags&FlagChecksum != 0 {
+			t.Error("checksum flag must be cleared")
+		}
+	})
+	t.Run("unset clear bit", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "vector.bin")
+		vec, vf, _ := OpenFileVector(path, 100)
+		vec.Set(5)
+		vec.Unset(3)
+		if err := vf.Close(); err != nil {
+			t.Fatal(err)
+		}
+		vec, vf, err := OpenFileVector(path, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = vf.Close() }()
+		if vec.Get(5) != 1 || vec.Size() != 1 {
+			t.Errorf("got %v with size %d after reopening", vec, vec.Size())
+		}
+	})
+	t.Run("read from", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "vector.bin")
+		vec, vf, _ := OpenFileVector(path, 100)
+		vec.Set(5)
+		src, _ := NewVectorWithOrder(1000, MSBFirst)
+		src.Set(500)
+		var buf bytes.Buffer
+		_, _ = src.WriteTo(&buf)
+		if _, err := vec.ReadFrom(&buf); !errors.Is(err, ErrFileBacked) {
+			t.Errorf("expected file backed error, got %v", err)
+		}
+		if err := vf.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if vec1 := load(t, path); sizeOf(vec1) != 100 || vec1.Get(5) != 1 {
+			t.Errorf("got %v", vec1)
+		}
+	})
+	t.Run("pool", func(t *testing.T) {
+		vec, vf, _ := OpenFileVector(filepath.Join(t.TempDir(), "vector.bin"), 64)
+		defer func() { _ = vf.Close() }()
+		vec.Set(1)
+		p := Pool{Kind: KindVector}
+		p.Put(vec)
+		for i := 0; i < 10; i++ {
+			if x, _ := p.Get(64); &bytesOf(x)[0] == &bytesOf(vec)[0] {
+				t.Fatal("file-backed vector taken from the pool")
+			}
+		}
+		if vec.Get(1) != 1 {
+			t.Error("file-backed vector was reset")
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "vector.bin")
+		_, vf, _ := OpenFileVector(path, 100)
+		_ = vf.Close()
+		if _, _, err := OpenFileVector(path, 200); !errors.Is(err, ErrNotEqualSize) {
+			t.Errorf("expected not equal size error, got %v", err)
+		}
+		if _, _, err := OpenFileVector(path, 0); !errors.Is(err, ErrZeroSize) {
+			t.Errorf("expected zero size error, got %v", err)
+		}
+		path = filepath.Join(dir, "compressed.bin")
+		f, _ := os.Create(path)
+		src, _ := NewVector(100)
+		_, _ = WriteCompressed(f, src)
+		_ = f.Close()
+		if _, _, err := OpenFileVector(path, 100); !errors.Is(err, ErrNotMappable) {
+			t.Errorf("expected not mappable error, got %v", err)
+		}
+	})
+}
+
+func must[T any](x T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
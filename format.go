@@ -0,0 +1,194 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// Default limits of formatted output. Precision (e.g. %.16b) overrides the limit.
+	fmtBitsLimit   = 1024
+	fmtWordsLimit  = 64
+	fmtRangesLimit = 256
+)
+
+// format writes human-readable representation of the vector according to verb:
+//   - %b - bit string, bit at position 0 goes first; %#b adds 0b prefix
+//   - %x, %X - hex of 64-bit little-endian words separated by spaces; %#x adds 0x prefix
+//   - %v, %s - list of set positions with ranges collapsed, e.g. {3,5,7-9}
+//   - %+v - same as %v with capacity and population count header
+//
+// Output longer than limit is truncated with ellipsis.
+func format(f fmt.State, verb rune, v Interface) {
+	limit, ok := f.Precision()
+	switch verb {
+	case 'b':
+		if !ok {
+			limit = fmtBitsLimit
+		}
+		formatBits(f, v, uint64(limit))
+	case 'x', 'X':
+		if !ok {
+			limit = fmtWordsLimit
+		}
+		formatWords(f, verb, v, uint64(limit))
+	case 'v', 's':
+		if !ok {
+			limit = fmtRangesLimit
+		}
+		if verb == 'v' && f.Flag('+') {
+			_, _ = fmt.Fprintf(f, "cap=%d popcnt=%d ", v.Capacity(), v.Popcnt())
+		}
+		formatRanges(f, v, limit)
+	default:
+		_, _ = fmt.Fprintf(f, "%%!%c(bitvector)", verb)
+	}
+}
+
+func formatBits(f fmt.State, v Interface, limit uint64) {
+	if f.Flag('#') {
+		_, _ = f.Write([]byte("0b"))
+	}
+	var (
+		buf [64]byte
+		off uint64
+	)
+	zeros := func(n uint64) {
+		for n > 0 {
+			k := min(n, uint64(len(buf)))
+			for i := uint64(0); i < k; i++ {
+				buf[i] = '0'
+			}
+			_, _ = f.Write(buf[:k])
+			n -= k
+		}
+	}
+	forEach(v, func(p uint64) bool {
+		if p >= limit {
+			return false
+		}
+		zeros(p - off)
+		_, _ = f.Write([]byte{'1'})
+		off = p + 1
+		return true
+	})
+	n := bitLen(v)
+	zeros(min(n, limit) - min(off, limit))
+	if n > limit {
+		_, _ = f.Write([]byte("..."))
+	}
+}
+
+func formatWords(f fmt.State, verb rune, v Interface, limit uint64) {
+	if f.Flag('#') {
+		_, _ = f.Write([]byte("0x"))
+	}
+	var (
+		raw [8]byte
+		buf [17]byte
+		i   uint64
+		w   uint64
+	)
+	flush := func() {
+		binary.LittleEndian.PutUint64(raw[:], w)
+		hex.Encode(buf[1:], raw[:])
+		if verb == 'X' {
+			for j := 1; j < len(buf); j++ {
+				if buf[j] >= 'a' {
+					buf[j] -= 'a' - 'A'
+				}
+			}
+		}
+		if i == 0 {
+			_, _ = f.Write(buf[1:])
+		} else {
+			buf[0] = ' '
+			_, _ = f.Write(buf[:])
+		}
+		i, w = i+1, 0
+	}
+	forEach(v, func(p uint64) bool {
+		if p/64 >= limit {
+			return false
+		}
+		for i < p/64 {
+			flush()
+		}
+		w |= 1 << (p % 64)
+		return true
+	})
+	n := (bitLen(v) + 63) / 64
+	for i < min(n, limit) {
+		flush()
+	}
+	if n > limit {
+		_, _ = f.Write([]byte("..."))
+	}
+}
+
+func formatRanges(f fmt.State, v Interface, limit int) {
+	var (
+		buf   []byte
+		lo    uint64
+		hi    uint64
+		count int
+		trunc bool
+	)
+	buf = append(buf, '{')
+	flush := func() {
+		if count > 0 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendUint(buf, lo, 10)
+		if hi > lo {
+			buf = append(buf, '-')
+			buf = strconv.AppendUint(buf, hi, 10)
+		}
+		count++
+	}
+	started := false
+	forEach(v, func(p uint64) bool {
+		if started && p == hi+1 {
+			hi = p
+			return true
+		}
+		if started {
+			if flush(); count >= limit {
+				trunc = true
+				return false
+			}
+		}
+		lo, hi, started = p, p, true
+		return true
+	})
+	if started && !trunc {
+		flush()
+	}
+	if trunc {
+		buf = append(buf, ",..."...)
+	}
+	buf = append(buf, '}')
+	_, _ = f.Write(buf)
+}
+
+// Format implements fmt.Formatter.
+func (vec *vector) Format(f fmt.State, verb rune) {
+	format(f, verb, vec)
+}
+
+// Format implements fmt.Formatter.
+func (vec *concurrentVector) Format(f fmt.State, verb rune) {
+	format(f, verb, vec)
+}
+
+// Format implements fmt.Formatter.
+func (vec *frozenVector) Format(f fmt.State, verb rune) {
+	format(f, verb, vec)
+}
+
+// Format implements fmt.Formatter.
+func (vec *roaringVector) Format(f fmt.State, verb rune) {
+	format(f, verb, vec)
+}
@@ -0,0 +1,62 @@
+package bitvector
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	prepare := func(vec Interface, _ error) Interface {
+		vec.Set(3)
+		vec.Set(5)
+		vec.Set(7)
+		vec.Set(8)
+		vec.Set(9)
+		return vec
+	}
+	stages := []struct {
+		format string
+		want   string
+	}{
+		{"%v", "{3,5,7-9}"},
+		{"%s", "{3,5,7-9}"},
+		{"%+v", "cap=16 popcnt=5 {3,5,7-9}"},
+		{"%.2v", "{3,5,...}"},
+		{"%b", "0001010111000000"},
+		{"%#b", "0b0001010111000000"},
+		{"%.8b", "00010101..."},
+		{"%x", "a803000000000000"},
+		{"%#X", "0xA803000000000000"},
+		{"%d", "%!d(bitvector)"},
+	}
+	for _, st := range stages {
+		t.Run(st.format, func(t *testing.T) {
+			vec := prepare(NewVector(10))
+			if r := fmt.Sprintf(st.format, vec); r != st.want {
+				t.Errorf("got %q, want %q", r, st.want)
+			}
+			if r := fmt.Sprintf(st.format, vec.(Freezer).Freeze()); r != st.want {
+				t.Errorf("frozen: got %q, want %q", r, st.want)
+			}
+		})
+	}
+	t.Run("concurrent", func(t *testing.T) {
+		vec := prepare(NewConcurrentVector(100, 0))
+		vec.Set(64)
+		if r := fmt.Sprintf("%+v", vec); r != "cap=128 popcnt=6 {3,5,7-9,64}" {
+			t.Errorf("unexpected %q", r)
+		}
+		if r := fmt.Sprintf("%x", vec); r != "a803000000000000 0100000000000000" {
+			t.Errorf("unexpected %q", r)
+		}
+		if r := fmt.Sprintf("%.1x", vec); r != "a803000000000000..." {
+			t.Errorf("unexpected %q", r)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		vec, _ := NewVector(1)
+		if r := fmt.Sprintf("%v %b", vec, vec); r != "{} 00000000" {
+			t.Errorf("unexpected %q", r)
+		}
+	})
+}
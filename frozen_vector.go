@@ -0,0 +1,30 @@
+package bitvector
+
+import "io"
+
+// frozenVector represents read-only view of frozen concurrent vector. It shares the buffer with the source vector and
+// reads it without atomics, since nobody can modify the source after freezing.
+type frozenVector struct {
+	vector
+	src *concurrentVector
+}
+
+// Difference calculates difference with the source concurrent vector, so other must be concurrent too.
+func (vec *frozenVector) Difference(other Interface) (uint64, error) {
+	return vec.src.Difference(other)
+}
+
+// Freeze returns the vector itself since it's already frozen.
+func (vec *frozenVector) Freeze() Interface {
+	return vec
+}
+
+// Clone returns mutable copy of the source concurrent vector.
+func (vec *frozenVector) Clone() Interface {
+	return vec.src.Clone()
+}
+
+// WriteTo writes the source concurrent vector dump.
+func (vec *frozenVector) WriteTo(w io.Writer) (int64, error) {
+	return vec.src.WriteTo(w)
+}
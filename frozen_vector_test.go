@@ -0,0 +1,85 @@
+package bitvector
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFrozenVector(t *testing.T) {
+	prepare := func(vec Interface) Interface {
+		vec.Set(3)
+		vec.Set(5)
+		vec.Set(7)
+		vec.Set(9)
+		return vec
+	}
+	assertFrozen := func(t *testing.T, src, vec Interface) {
+		if vec.Set(1) || vec.Unset(3) || vec.Xor(5) || src.Set(1) {
+			t.Error("frozen vector was modified")
+		}
+		if err := vec.Merge(src.Clone()); err != ErrFrozen {
+			t.Errorf("expected frozen error, got %v", err)
+		}
+		if err := src.Filter(src.Clone()); err != ErrFrozen {
+			t.Errorf("expected frozen error, got %v", err)
+		}
+		vec.Reset()
+		vec.Invert()
+		chk := map[int]uint8{3: 1, 5: 1, 7: 1, 9: 1}
+		for i := 0; i < 10; i++ {
+			if chk[i] != vec.Get(uint64(i)) {
+				t.Errorf("bit %d mismatch", i)
+			}
+		}
+		if vec.Popcnt() != 4 {
+			t.Errorf("popcnt mismatch: %d", vec.Popcnt())
+		}
+		if _, err := vec.ReadFrom(bytes.NewReader(nil)); err != ErrFrozen {
+			t.Errorf("expected frozen error, got %v", err)
+		}
+		clone := vec.Clone()
+		if !clone.Set(1) || clone.Get(1) != 1 || vec.Get(1) != 0 {
+			t.Error("clone must be mutable")
+		}
+	}
+	t.Run("vector", func(t *testing.T) {
+		vec, _ := NewVector(10)
+		src := prepare(vec)
+		assertFrozen(t, src, src.(Freezer).Freeze())
+	})
+	t.Run("concurrent vector", func(t *testing.T) {
+		vec, _ := NewConcurrentVector(10, 0)
+		src := prepare(vec)
+		frz := src.(Freezer).Freeze()
+		assertFrozen(t, src, frz)
+		if &bytesOf(frz)[0] != &bytesOf(src)[0] {
+			t.Error("buffer isn't shared")
+		}
+		if _, ok := frz.Clone().(*concurrentVector); !ok {
+			t.Error("clone must keep source type")
+		}
+		if diff, err := frz.Difference(src.Clone()); diff != 0 || err != nil {
+			t.Errorf("difference error: %v, %v", diff, err)
+		}
+		dense, _ := NewVector(10)
+		if _, err := dense.Difference(frz); err != ErrWrongType {
+			t.Errorf("expected wrong type error, got %v", err)
+		}
+		if err := dense.Merge(frz); err != ErrWrongType {
+			t.Errorf("expected wrong type error, got %v", err)
+		}
+		if _, err := frz.Difference(dense); err != ErrWrongType {
+			t.Errorf("expected wrong type error, got %v", err)
+		}
+		dst, _ := NewConcurrentVector(10, 0)
+		if err := dst.Merge(frz); err != nil || Compare(dst, src) != 0 {
+			t.Errorf("merge error: %v, %v != %v", err, dst, src)
+		}
+		var buf0, buf1 bytes.Buffer
+		_, _ = src.WriteTo(&buf0)
+		_, _ = frz.WriteTo(&buf1)
+		if !bytes.Equal(buf0.Bytes(), buf1.Bytes()) {
+			t.Error("dump mismatch")
+		}
+	})
+}
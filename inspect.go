@@ -0,0 +1,117 @@
+package bitvector
+
+import (
+	"io"
+	"math"
+)
+
+// Dump header flags reported by Inspect.
+const (
+	// FlagMSBFirst indicates vector with MSB-first bit order.
+	FlagMSBFirst = vectorFlagMSB
+	// FlagChecksum indicates that the dump ends with CRC32C checksum.
+	FlagChecksum = dumpFlagChecksum
+	// FlagCompressed indicates compressed payload, see WriteCompressed.
+	FlagCompressed = dumpFlagCompressed
+	// FlagBlocks indicates payload encoded by blocks, see WriteAdaptive.
+	FlagBlocks = dumpFlagBlocks
+	// FlagMetadata indicates header with metadata, see SetMetadata.
+	FlagMetadata = dumpFlagMetadata
+)
+
+// Header describes a vector dump.
+type Header struct {
+	// Kind of dumped vector.
+	Kind Kind
+	// Version of dump format.
+	Version int
+	// Capacity of dumped vector in bits. Always zero for roaring vector.
+	Capacity uint64
+	// Size is a stored number of set bits. Always zero for roaring vector.
+	Size uint64
+	// WriteAttemptsLimit of concurrent vector as given to NewConcurrentVector.
+	WriteAttemptsLimit uint64
+	// Flags contains header flags of vector dump, see Flag* constants.
+	Flags uint64
+	// Metadata of dumped vector, see SetMetadata.
+	Metadata Metadata
+	// HeaderLen is a length of the header in bytes including metadata.
+	HeaderLen int64
+	// PayloadLen is a length of data following the header in bytes including checksum trailer. Roaring vector and
+	// encoded dumps don't store their lengths, so PayloadLen is a number of remaining bytes if reader implements
+	// io.Seeker, otherwise -1.
+	PayloadLen int64
+}
+
+// Inspect reads the fixed header of a dump written by WriteTo of any vector without loading the payload.
+//
+// If r implements io.Seeker, the header is validated against the stream size and ErrTruncated returns if the payload
+// is shorter than the header claims. The position of seeker stays right after the header.
+func Inspect(r io.Reader) (h Header, err error) {
+	dh, err := readHeader(r, 0)
+	if err != nil {
+		return
+	}
+	h.Kind, h.Version, h.Flags, h.HeaderLen = dh.kind, dh.ver, dh.flags, int64(dh.len)
+	h.Metadata = dh.md
+	var need uint64
+	switch h.Kind {
+	case KindVector, KindConcurrentVector:
+		h.Capacity, h.Size = dh.fields[0], dh.fields[1]
+		if h.Kind == KindVector {
+			need = h.Capacity/8 + 1
+		} else {
+			need = (h.Capacity/32 + 1) * 4
+			// Dump stores the number of attempts, i.e. limit plus one.
+			if lim := dh.fields[2]; lim > 0 {
+				h.WriteAttemptsLimit = lim - 1
+			}
+		}
+	case KindRoaringVector:
+		if dh.fields[0] > math.MaxUint32+1 {
+			return h, ErrCorrupted
+		}
+		// Keys and number of bitmaps.
+		need = dh.fields[0]*4 + 8
+	}
+	// Length of encoded payload is unknown, but compressed payload contains at least terminating frame.
+	encoded := h.Flags&(dumpFlagCompressed|dumpFlagBlocks) != 0
+	if encoded {
+		need = 0
+		if h.Flags&dumpFlagCompressed != 0 {
+			need = 4
+		}
+	}
+	if h.Flags&dumpFlagChecksum != 0 {
+		need += 4
+	}
+	if need > math.MaxInt64 {
+		return h, ErrCorrupted
+	}
+	h.PayloadLen = int64(need)
+	if h.Kind == KindRoaringVector || encoded {
+		h.PayloadLen = -1
+	}
+
+	s, ok := r.(io.Seeker)
+	if !ok {
+		return
+	}
+	var cur, end int64
+	if cur, err = s.Seek(0, io.SeekCurrent); err != nil {
+		return
+	}
+	if end, err = s.Seek(0, io.SeekEnd); err != nil {
+		return
+	}
+	if _, err = s.Seek(cur, io.SeekStart); err != nil {
+		return
+	}
+	if h.PayloadLen < 0 {
+		h.PayloadLen = end - cur
+	}
+	if end-cur < int64(need) {
+		err = ErrTruncated
+	}
+	return
+}
@@ -0,0 +1,94 @@
+package bitvector
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	dump := func(vec Interface) []byte {
+		var buf bytes.Buffer
+		_, _ = vec.WriteTo(&buf)
+		return buf.Bytes()
+	}
+	t.Run("vector", func(t *testing.T) {
+		vec, _ := Parse("3,5,7-9,100", KindVector)
+		p := dump(vec)
+		h, err := Inspect(bytes.NewReader(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Header{Kind: KindVector, Version: DumpVersion, Capacity: 101, Size: 6, Flags: FlagChecksum, HeaderLen: 40,
+			PayloadLen: 17}
+		if !reflect.DeepEqual(h, want) {
+			t.Errorf("got %+v, want %+v", h, want)
+		}
+		if h.HeaderLen+h.PayloadLen != int64(len(p)) {
+			t.Errorf("dump length mismatch")
+		}
+	})
+	t.Run("concurrent vector", func(t *testing.T) {
+		vec, _ := NewConcurrentVector(100, 5)
+		vec.Set(99)
+		p := dump(vec)
+		h, err := Inspect(bytes.NewReader(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Header{Kind: KindConcurrentVector, Version: DumpVersion, Capacity: 100, Size: 1, WriteAttemptsLimit: 5,
+			Flags: FlagChecksum, HeaderLen: 48, PayloadLen: 20}
+		if !reflect.DeepEqual(h, want) {
+			t.Errorf("got %+v, want %+v", h, want)
+		}
+		if h.HeaderLen+h.PayloadLen != int64(len(p)) {
+			t.Errorf("dump length mismatch")
+		}
+	})
+	t.Run("roaring vector", func(t *testing.T) {
+		vec, _ := Parse("3,5,7-9,100", KindRoaringVector)
+		p := dump(vec)
+		h, err := Inspect(bytes.NewReader(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Kind != KindRoaringVector || h.HeaderLen+h.PayloadLen != int64(len(p)) {
+			t.Errorf("unexpected header %+v", h)
+		}
+		// Payload length is unknown without seeker.
+		if h, _ = Inspect(bytes.NewBuffer(p)); h.PayloadLen != -1 {
+			t.Errorf("unexpected payload length %d", h.PayloadLen)
+		}
+	})
+	t.Run("seek position", func(t *testing.T) {
+		vec, _ := Parse("3,5", KindVector)
+		r := bytes.NewReader(dump(vec))
+		h, err := Inspect(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Len() != int(h.PayloadLen) {
+			t.Errorf("reader must stay after the header, %d bytes left", r.Len())
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+			vec, _ := Parse("3,5,7-9,100", kind)
+			p := dump(vec)
+			for _, n := range []int{8, 20, len(p) - 1} {
+				if kind == KindRoaringVector && n == len(p)-1 {
+					// Bitmaps length is unknown until they are read.
+					continue
+				}
+				if _, err := Inspect(bytes.NewReader(p[:n])); err != ErrTruncated {
+					t.Errorf("%s of %d bytes: expected truncated error, got %v", kind, n, err)
+				}
+			}
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := Inspect(bytes.NewReader(make([]byte, 64))); err != ErrInvalidSignature {
+			t.Errorf("expected invalid signature error, got %v", err)
+		}
+	})
+}
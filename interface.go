@@ -33,3 +33,21 @@ type Interface interface {
 	// Reset resets the whole bit array.
 	Reset()
 }
+
+// Freezer is implemented by vectors which can be made read-only.
+type Freezer interface {
+	// Freeze makes the vector read-only and returns its immutable version.
+	Freeze() Interface
+}
+
+// RangeSetter is implemented by vectors which can write range of bits at once.
+type RangeSetter interface {
+	// SetRange writes bits in range [lo, hi).
+	SetRange(lo, hi uint64) bool
+}
+
+// Dense is implemented by vectors with dense representation in memory, see FromBytes.
+type Dense interface {
+	// Bytes returns underlying memory of the vector.
+	Bytes() []byte
+}
@@ -0,0 +1,462 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+const (
+	journalSignature = 0x4c4e524a56544942
+
+	// Both the journal header and records are of this size.
+	journalRecordSz = 24
+)
+
+// Journal record operations.
+const (
+	journalSet = iota + 1
+	journalUnset
+	journalXor
+	journalSetRange
+	journalReset
+	journalInvert
+)
+
+// Journal is a vector wrapper writing all modifications ahead to an append-only journal file.
+//
+// Set, Unset, Xor and SetRange are applied to the vector and logged if the vector accepts them, Reset and Invert can't
+// be rejected and are logged before applying. Result is reported after both steps, thus successful modifications
+// survive the crash of the process and may be restored by Recover. The vector is dumped to the snapshot file on every
+// checkpoint, after that the journal starts over. Merge, Filter, ReadFrom and SetMetadata aren't logged and make
+// checkpoint immediately.
+//
+// If writing to the journal fails, the modification is reverted and the error is available by Err. Journal is safe for
+// concurrent use if the wrapped vector is.
+type Journal struct {
+	mu  sync.Mutex
+	vec Interface
+	// Paths to snapshot and journal files.
+	snapshot, journal string
+	f                 *os.File
+	// Number of records since the last checkpoint and number of records between checkpoints.
+	n, interval int
+	err         error
+}
+
+// NewJournal wraps the vector and makes the first checkpoint to snapshot and journal files.
+//
+// Checkpoint is made after every interval modifications, zero or negative interval disables periodic checkpoints.
+// Existing files are overwritten, so use Recover to restore the vector before wrapping it. Frozen vector can't be
+// wrapped, ErrFrozen returns.
+func NewJournal(vec Interface, snapshot, journal string, interval int) (*Journal, error) {
+	if isFrozen(vec) {
+		return nil, ErrFrozen
+	}
+	j := &Journal{
+		vec:      vec,
+		snapshot: snapshot,
+		journal:  journal,
+		interval: interval,
+	}
+	if err := j.Checkpoint(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Recover loads the snapshot and replays the journal written by Journal on it.
+//
+// Torn last record of the journal is ignored, since it has never been applied to the vector. The journal is ignored
+// completely if it was started before the given snapshot. ErrCorrupted returns if the journal is damaged elsewhere.
+func Recover(snapshot, journal io.Reader) (Interface, error) {
+	cr := crcReader{r: snapshot}
+	vec, err := Load(&cr)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf [journalRecordSz]byte
+	if _, err = io.ReadFull(journal, buf[:]); err != nil {
+		return nil, truncated(err)
+	}
+	if binary.LittleEndian.Uint64(buf[0:]) != journalSignature {
+		return nil, ErrInvalidSignature
+	}
+	if binary.LittleEndian.Uint64(buf[8:]) != uint64(cr.n) || binary.LittleEndian.Uint32(buf[16:]) != cr.crc {
+		// Snapshot was taken after the journal start and contains all its records.
+		return vec, nil
+	}
+
+	for {
+		_, err = io.ReadFull(journal, buf[:])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return vec, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if crc32.Checksum(buf[:20], crcTable) != binary.LittleEndian.Uint32(buf[20:]) {
+			// Damaged record is tolerated only at the end of the journal.
+			if _, err = io.ReadFull(journal, buf[:1]); err == io.EOF {
+				return vec, nil
+			}
+			return nil, ErrCorrupted
+		}
+		if !replay(vec, buf[0], binary.LittleEndian.Uint64(buf[4:]), binary.LittleEndian.Uint64(buf[12:])) {
+			return nil, ErrCorrupted
+		}
+	}
+}
+
+// replay applies journal record to the vector. Returns false on unknown operation.
+func replay(vec Interface, op uint8, lo, hi uint64) bool {
+	switch op {
+	case journalSet:
+		vec.Set(lo)
+	case journalUnset:
+		vec.Unset(lo)
+	case journalXor:
+		vec.Xor(lo)
+	case journalSetRange:
+		setRange(vec, lo, hi)
+	case journalReset:
+		vec.Reset()
+	case journalInvert:
+		vec.Invert()
+	default:
+		return false
+	}
+	return true
+}
+
+// Checkpoint dumps the vector to the snapshot file and starts new journal.
+//
+// Both files are replaced atomically by renaming, so the crash at any moment leaves either the previous snapshot with
+// its journal or the new snapshot, which Recover doesn't replay the previous journal on.
+func (j *Journal) Checkpoint() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.checkpoint()
+}
+
+func (j *Journal) checkpoint() (err error) {
+	if j.err != nil {
+		return j.err
+	}
+	defer func() {
+		if err != nil {
+			j.err = err
+		}
+	}()
+
+	tmp := j.snapshot + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return
+	}
+	cw := crcWriter{w: f}
+	if _, err = j.vec.WriteTo(&cw); err == nil {
+		err = f.Sync()
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return
+	}
+	if err = os.Rename(tmp, j.snapshot); err != nil {
+		return
+	}
+	if err = syncDir(j.snapshot); err != nil {
+		return
+	}
+
+	// New journal refers to the snapshot by its length and checksum.
+	tmp = j.journal + ".tmp"
+	if f, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644); err != nil {
+		return
+	}
+	var buf [journalRecordSz]byte
+	binary.LittleEndian.PutUint64(buf[0:], journalSignature)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(cw.n))
+	binary.LittleEndian.PutUint32(buf[16:], cw.crc)
+	if _, err = f.Write(buf[:]); err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, j.journal)
+	}
+	if err == nil {
+		err = syncDir(j.journal)
+	}
+	if err != nil {
+		_ = f.Close()
+		return
+	}
+	if j.f != nil {
+		_ = j.f.Close()
+	}
+	j.f, j.n = f, 0
+	return
+}
+
+// syncDir flushes the directory containing the file at path to stable storage, so renaming of the file is durable.
+func syncDir(path string) error {
+	if runtime.GOOS == "windows" {
+		// Directories can't be flushed on Windows.
+		return nil
+	}
+	d, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if err1 := d.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// prepare checks that the journal accepts modifications and makes checkpoint if needed. Returns false if the
+// modification must not be applied.
+func (j *Journal) prepare() bool {
+	if j.err != nil {
+		return false
+	}
+	if j.interval > 0 && j.n >= j.interval {
+		return j.checkpoint() == nil
+	}
+	return true
+}
+
+// log writes the record to the journal. Returns false if writing fails.
+func (j *Journal) log(op uint8, lo, hi uint64) bool {
+	var buf [journalRecordSz]byte
+	buf[0] = op
+	binary.LittleEndian.PutUint64(buf[4:], lo)
+	binary.LittleEndian.PutUint64(buf[12:], hi)
+	binary.LittleEndian.PutUint32(buf[20:], crc32.Checksum(buf[:20], crcTable))
+	if _, err := j.f.Write(buf[:]); err != nil {
+		j.err = err
+		return false
+	}
+	j.n++
+	return true
+}
+
+// commit logs the modification if the vector has applied it. Otherwise, or if logging fails, undo reverts the
+// modification, since it may be applied partially.
+func (j *Journal) commit(applied bool, op uint8, lo, hi uint64, undo func()) bool {
+	if applied && j.log(op, lo, hi) {
+		return true
+	}
+	undo()
+	return false
+}
+
+// restore writes bit value x at position i if the vector has another value.
+func restore(vec Interface, i uint64, x uint8) {
+	switch {
+	case vec.Get(i) == x:
+	case x == 0:
+		vec.Unset(i)
+	default:
+		vec.Set(i)
+	}
+}
+
+// Err returns the first error of writing journal or snapshot. All further modifications fail after an error.
+func (j *Journal) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Sync flushes the journal to stable storage.
+func (j *Journal) Sync() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return os.ErrClosed
+	}
+	return j.f.Sync()
+}
+
+// Close syncs and closes the journal. All further modifications fail.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Sync()
+	if err1 := j.f.Close(); err == nil {
+		err = err1
+	}
+	j.f = nil
+	if j.err == nil {
+		j.err = os.ErrClosed
+	}
+	return err
+}
+
+func (j *Journal) Set(i uint64) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.prepare() {
+		return false
+	}
+	x := j.vec.Get(i)
+	return j.commit(j.vec.Set(i), journalSet, i, 0, func() { restore(j.vec, i, x) })
+}
+
+func (j *Journal) Xor(i uint64) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.prepare() {
+		return false
+	}
+	x := j.vec.Get(i)
+	return j.commit(j.vec.Xor(i), journalXor, i, 0, func() { restore(j.vec, i, x) })
+}
+
+func (j *Journal) Unset(i uint64) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.prepare() {
+		return false
+	}
+	x := j.vec.Get(i)
+	return j.commit(j.vec.Unset(i), journalUnset, i, 0, func() { restore(j.vec, i, x) })
+}
+
+func (j *Journal) SetRange(lo, hi uint64) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.prepare() {
+		return false
+	}
+	// Keep clear positions of the range to revert the modification. Dense vectors have no positions beyond capacity.
+	var clr []prange
+	end := hi
+	if _, ok := j.vec.(*roaringVector); !ok {
+		end = min(hi, j.vec.Capacity())
+	}
+	for i := lo; i < end; i++ {
+		if j.vec.Get(i) != 0 {
+			continue
+		}
+		if n := len(clr); n > 0 && clr[n-1].hi == i {
+			clr[n-1].hi++
+		} else {
+			clr = append(clr, prange{lo: i, hi: i + 1})
+		}
+	}
+	return j.commit(setRange(j.vec, lo, hi), journalSetRange, lo, hi, func() {
+		for _, r := range clr {
+			for i := r.lo; i < r.hi; i++ {
+				restore(j.vec, i, 0)
+			}
+		}
+	})
+}
+
+// Reset can't be rejected by the vector, so it's logged before applying.
+func (j *Journal) Reset() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.prepare() && j.log(journalReset, 0, 0) {
+		j.vec.Reset()
+	}
+}
+
+// Invert can't be rejected by the vector, so it's logged before applying.
+func (j *Journal) Invert() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.prepare() && j.log(journalInvert, 0, 0) {
+		j.vec.Invert()
+	}
+}
+
+func (j *Journal) Merge(p Interface) error {
+	return j.apply(func() error { return j.vec.Merge(unwrapJournal(p)) })
+}
+
+func (j *Journal) Filter(p Interface) error {
+	return j.apply(func() error { return j.vec.Filter(unwrapJournal(p)) })
+}
+
+// ReadFrom reads the vector dump and makes checkpoint.
+func (j *Journal) ReadFrom(r io.Reader) (n int64, err error) {
+	err = j.apply(func() error {
+		n, err = j.vec.ReadFrom(r)
+		return err
+	})
+	return
+}
+
+// apply calls modification fn which can't be logged and makes checkpoint after it.
+func (j *Journal) apply(fn func() error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.err != nil {
+		return j.err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return j.checkpoint()
+}
+
+func (j *Journal) WriteTo(w io.Writer) (int64, error) {
+	return j.vec.WriteTo(w)
+}
+
+func (j *Journal) Get(i uint64) uint8 {
+	return j.vec.Get(i)
+}
+
+func (j *Journal) Size() uint64 {
+	return j.vec.Size()
+}
+
+func (j *Journal) Capacity() uint64 {
+	return j.vec.Capacity()
+}
+
+func (j *Journal) Popcnt() uint64 {
+	return j.vec.Popcnt()
+}
+
+func (j *Journal) Difference(p Interface) (uint64, error) {
+	return j.vec.Difference(unwrapJournal(p))
+}
+
+// Clone returns a copy of the wrapped vector. Modifications of the copy aren't logged.
+func (j *Journal) Clone() Interface {
+	return j.vec.Clone()
+}
+
+// Bytes returns underlying memory of the wrapped vector or nil if it has no dense representation.
+func (j *Journal) Bytes() []byte {
+	return bytesOf(j.vec)
+}
+
+// Vector returns the wrapped vector. Its modifications aren't logged.
+func (j *Journal) Vector() Interface {
+	return j.vec
+}
+
+// unwrapJournal returns the vector wrapped by journal or vec itself.
+func unwrapJournal(vec Interface) Interface {
+	if j, ok := vec.(*Journal); ok {
+		return j.vec
+	}
+	return vec
+}
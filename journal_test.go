@@ -0,0 +1,209 @@
+package bitvector
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	restore := func(t *testing.T, snapshot, journal string) Interface {
+		sf, err := os.Open(snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = sf.Close() }()
+		jf, err := os.Open(journal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = jf.Close() }()
+		vec, err := Recover(sf, jf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vec
+	}
+	files := func(t *testing.T) (string, string) {
+		dir := t.TempDir()
+		return filepath.Join(dir, "vector.bin"), filepath.Join(dir, "vector.log")
+	}
+	for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+		t.Run(kind.String(), func(t *testing.T) {
+			snapshot, journal := files(t)
+			vec, _ := newOfKind(kind, 100, 0)
+			vec.Set(1)
+			j, err := NewJournal(vec, snapshot, journal, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			j.Set(10)
+			j.SetRange(20, 30)
+			j.Set(3)
+			// Journal isn't closed to simulate the crash.
+			want, _ := Parse("1,3,10,20-29", kind)
+			if vec1 := restore(t, snapshot, journal); Compare(want, vec1) != 0 || Compare(want, j) != 0 {
+				t.Errorf("got %v and %v, want %v", vec1, j, want)
+			}
+			_ = j.Close()
+			if j.Set(5) || !errors.Is(j.Err(), os.ErrClosed) {
+				t.Error("closed journal must not modify the vector")
+			}
+		})
+	}
+	t.Run("checkpoint", func(t *testing.T) {
+		snapshot, journal := files(t)
+		vec, _ := NewVector(100)
+		j, err := NewJournal(vec, snapshot, journal, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = j.Close() }()
+		want, _ := NewVector(100)
+		for _, x := range []Interface{j, want} {
+			for i := uint64(0); i < 10; i++ {
+				x.Xor(i)
+			}
+			x.Invert()
+			x.Set(5)
+			x.Unset(60)
+		}
+		// Journal contains the header and records since the last checkpoint only.
+		if fi, _ := os.Stat(journal); fi.Size() != 2*journalRecordSz {
+			t.Errorf("unexpected journal size %d", fi.Size())
+		}
+		if vec1 := restore(t, snapshot, journal); Compare(want, vec1) != 0 {
+			t.Errorf("got %v, want %v", vec1, want)
+		}
+
+		// Journal started before the snapshot must not be replayed.
+		stale, _ := os.ReadFile(journal)
+		other, _ := NewVector(100)
+		other.Set(1)
+		if err = j.Merge(other); err != nil {
+			t.Fatal(err)
+		}
+		want.Set(1)
+		_ = os.WriteFile(journal, stale, 0644)
+		if vec1 := restore(t, snapshot, journal); Compare(want, vec1) != 0 {
+			t.Errorf("got %v, want %v", vec1, want)
+		}
+	})
+	t.Run("wrapped", func(t *testing.T) {
+		for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+			snapshot, journal := files(t)
+			vec, _ := Parse("3,5,7-9", kind)
+			j, err := NewJournal(vec, snapshot, journal, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			md := Metadata{"job": "daily"}
+			if err = SetMetadata(j, md); err != nil || !reflect.DeepEqual(MetadataOf(j), md) {
+				t.Errorf("%s: metadata %v, error %v", kind, MetadataOf(j), err)
+			}
+			// Metadata change makes checkpoint.
+			if vec1 := restore(t, snapshot, journal); !reflect.DeepEqual(MetadataOf(vec1), md) {
+				t.Errorf("%s: restored metadata %v", kind, MetadataOf(vec1))
+			}
+			var buf bytes.Buffer
+			if _, err = WriteCompressed(&buf, j); err != nil {
+				t.Errorf("%s: %v", kind, err)
+			}
+			if _, err = WriteAdaptive(&buf, j); err != nil {
+				t.Errorf("%s: %v", kind, err)
+			}
+			other, _ := newOfKind(kind, 10, 0)
+			if n, err := other.Difference(j); err != nil || n != 5 {
+				t.Errorf("%s: got difference %d, error %v", kind, n, err)
+			}
+			if err = other.Merge(j); err != nil {
+				t.Errorf("%s: %v", kind, err)
+			}
+			_ = j.Close()
+		}
+	})
+	t.Run("rejected", func(t *testing.T) {
+		snapshot, journal := files(t)
+		vec, _ := NewVector(100)
+		frz, _ := NewVector(100)
+		if _, err := NewJournal(frz.(Freezer).Freeze(), snapshot, journal, 0); !errors.Is(err, ErrFrozen) {
+			t.Errorf("expected frozen error, got %v", err)
+		}
+		// Vector rejects the second flip and all sets.
+		j, err := NewJournal(&failingVector{Interface: vec, fail: 2}, snapshot, journal, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j.Xor(1)
+		if j.Xor(3) || j.Set(7) || j.SetRange(10, 20) {
+			t.Error("rejected modifications must fail")
+		}
+		if vec1 := restore(t, snapshot, journal); Compare(vec, vec1) != 0 || vec.Get(1) != 1 {
+			t.Errorf("got %v, want %v", vec1, vec)
+		}
+		_ = j.Close()
+	})
+	t.Run("log failed", func(t *testing.T) {
+		snapshot, journal := files(t)
+		vec, _ := NewVector(100)
+		j, err := NewJournal(vec, snapshot, journal, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j.Set(1)
+		// Closed file makes writing of records fail.
+		_ = j.f.Close()
+		if j.Set(7) || j.SetRange(10, 20) || vec.Get(7) != 0 || vec.Get(15) != 0 || j.Err() == nil {
+			t.Errorf("modification must be reverted, got %v", vec)
+		}
+		if vec1 := restore(t, snapshot, journal); Compare(vec, vec1) != 0 {
+			t.Errorf("got %v, want %v", vec1, vec)
+		}
+	})
+	t.Run("sync dir", func(t *testing.T) {
+		snapshot, _ := files(t)
+		if err := syncDir(snapshot); err != nil {
+			t.Error(err)
+		}
+		if err := syncDir(filepath.Join(snapshot, "missing", "vector.bin")); err == nil && runtime.GOOS != "windows" {
+			t.Error("expected error of missing directory")
+		}
+	})
+	t.Run("torn", func(t *testing.T) {
+		snapshot, journal := files(t)
+		vec, _ := NewVector(100)
+		j, err := NewJournal(vec, snapshot, journal, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j.Set(1)
+		j.Set(2)
+		j.Set(3)
+		_ = j.Close()
+		buf, _ := os.ReadFile(journal)
+
+		want, _ := Parse("1,2", KindVector)
+		// Partially written and damaged last record.
+		_ = os.WriteFile(journal, buf[:len(buf)-5], 0644)
+		if vec1 := restore(t, snapshot, journal); Compare(want, vec1) != 0 {
+			t.Errorf("got %v, want %v", vec1, want)
+		}
+		buf[len(buf)-10] ^= 0xff
+		_ = os.WriteFile(journal, buf, 0644)
+		if vec1 := restore(t, snapshot, journal); Compare(want, vec1) != 0 {
+			t.Errorf("got %v, want %v", vec1, want)
+		}
+
+		// Damaged record in the middle.
+		buf[len(buf)-10] ^= 0xff
+		buf[len(buf)-30] ^= 0xff
+		sf, _ := os.ReadFile(snapshot)
+		if _, err = Recover(bytes.NewReader(sf), bytes.NewReader(buf)); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("expected corrupted error, got %v", err)
+		}
+	})
+}
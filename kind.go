@@ -0,0 +1,55 @@
+package bitvector
+
+// Kind represents type of bit array implementation.
+type Kind uint8
+
+const (
+	// KindVector represents simple bit array, see NewVector.
+	KindVector Kind = iota
+	// KindConcurrentVector represents concurrent bit array, see NewConcurrentVector.
+	KindConcurrentVector
+	// KindRoaringVector represents roaring bit array.
+	KindRoaringVector
+)
+
+// defaultWriteAttemptsLimit applies to concurrent vectors made without explicit limit, e.g. by Parse.
+const defaultWriteAttemptsLimit = 16
+
+// newOfKind makes new bit array of given kind and size.
+func newOfKind(kind Kind, size, writeAttemptsLimit uint64) (Interface, error) {
+	switch kind {
+	case KindVector:
+		return NewVector(size)
+	case KindConcurrentVector:
+		return NewConcurrentVector(size, writeAttemptsLimit)
+	case KindRoaringVector:
+		return &roaringVector{}, nil
+	default:
+		return nil, ErrWrongType
+	}
+}
+
+// kindOf returns kind of given vector.
+func kindOf(v Interface) Kind {
+	switch unwrapJournal(v).(type) {
+	case *concurrentVector, *frozenVector:
+		return KindConcurrentVector
+	case *roaringVector:
+		return KindRoaringVector
+	default:
+		return KindVector
+	}
+}
+
+func (k Kind) String() string {
+	switch k {
+	case KindVector:
+		return "vector"
+	case KindConcurrentVector:
+		return "concurrent vector"
+	case KindRoaringVector:
+		return "roaring vector"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,86 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Load reads a dump written by WriteTo of any vector and returns the vector of corresponding type.
+//
+// The type is detected by dump signature, thus the caller doesn't need to know it in advance.
+func Load(r io.Reader) (Interface, error) {
+	var sig [8]byte
+	if _, err := io.ReadFull(r, sig[:]); err != nil {
+		return nil, err
+	}
+	var vec Interface
+	switch binary.LittleEndian.Uint64(sig[:]) {
+	case vectorDumpSignature:
+		vec = &vector{}
+	case cnVectorDumpSignature:
+		vec = &concurrentVector{}
+	case roaringVectorDumpSignature:
+		vec = &roaringVector{}
+	default:
+		return nil, ErrInvalidSignature
+	}
+	if _, err := vec.ReadFrom(&prefixReader{p: sig[:], r: r}); err != nil {
+		return nil, err
+	}
+	return vec, nil
+}
+
+// LoadAs reads a dump of any vector like Load and converts the result to vector of given kind if necessary.
+//
+// Concurrent vectors made by conversion get default write attempts limit.
+func LoadAs(r io.Reader, kind Kind) (Interface, error) {
+	vec, err := Load(r)
+	if err != nil {
+		return nil, err
+	}
+	if kindOf(vec) == kind {
+		return vec, nil
+	}
+	return convertTo(vec, kind)
+}
+
+// convertTo copies the vector to new vector of given kind.
+func convertTo(vec Interface, kind Kind) (Interface, error) {
+	dst, err := newOfKind(kind, sizeOf(vec), defaultWriteAttemptsLimit)
+	if err != nil {
+		return nil, err
+	}
+	put := orWordsOf(dst)
+	it := newWordIter(vec)
+	for i, w, ok := it.next(); ok; i, w, ok = it.next() {
+		put(i, w)
+	}
+	recount(dst)
+	return dst, nil
+}
+
+// prefixReader returns already consumed prefix p before reading from r.
+//
+// Read containing the prefix is filled completely, since ReadFrom implementations expect the dump header to be read
+// by a single call.
+type prefixReader struct {
+	p []byte
+	r io.Reader
+}
+
+func (r *prefixReader) Read(p []byte) (n int, err error) {
+	if len(r.p) == 0 {
+		return r.r.Read(p)
+	}
+	n = copy(p, r.p)
+	r.p = r.p[n:]
+	if n < len(p) {
+		var m int
+		m, err = io.ReadFull(r.r, p[n:])
+		n += m
+		if err == io.ErrUnexpectedEOF || (err == io.EOF && n > 0) {
+			err = nil
+		}
+	}
+	return
+}
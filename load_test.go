@@ -0,0 +1,53 @@
+package bitvector
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	const pos = "3,5,7-9,100"
+	kinds := []Kind{KindVector, KindConcurrentVector, KindRoaringVector}
+	t.Run("detect", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse(pos, kind)
+			var buf bytes.Buffer
+			if _, err := vec.WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			vec1, err := Load(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kindOf(vec1) != kind || Compare(vec, vec1) != 0 {
+				t.Errorf("%s: got %s %v, want %v", kind, kindOf(vec1), vec1, vec)
+			}
+		}
+	})
+	t.Run("convert", func(t *testing.T) {
+		for _, src := range kinds {
+			for _, dst := range kinds {
+				vec, _ := Parse(pos, src)
+				var buf bytes.Buffer
+				if _, err := vec.WriteTo(&buf); err != nil {
+					t.Fatal(err)
+				}
+				vec1, err := LoadAs(&buf, dst)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if kindOf(vec1) != dst || Compare(vec, vec1) != 0 || vec1.Popcnt() != 6 {
+					t.Errorf("%s to %s: got %v, want %v", src, dst, vec1, vec)
+				}
+			}
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := Load(bytes.NewReader(make([]byte, 64))); err != ErrInvalidSignature {
+			t.Errorf("expected invalid signature error, got %v", err)
+		}
+		if _, err := Load(bytes.NewReader(nil)); err == nil {
+			t.Error("expected error on empty input")
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"io"
+	"maps"
+	"math"
+	"slices"
+)
+
+const (
+	// Dump header flag common for all vector types: the header is followed by metadata block.
+	dumpFlagMetadata = 1 << 4
+
+	// Max length of encoded metadata.
+	maxMetadataLen = 1 << 20
+)
+
+// Metadata is a set of user key/value pairs stored in dump header, e.g. creation time or source of the vector.
+type Metadata map[string]string
+
+// SetMetadata sets metadata of the vector.
+//
+// Metadata is written to the dump header by WriteTo and friends, restored by ReadFrom and Load, reported by Inspect
+// and copied by Clone. The map is copied, nil or empty md removes metadata.
+func SetMetadata(vec Interface, md Metadata) error {
+	if len(md) == 0 {
+		md = nil
+	}
+	var sz int
+	for k, v := range md {
+		if len(k) > math.MaxUint16 {
+			return ErrMetadataTooLarge
+		}
+		sz += 6 + len(k) + len(v)
+	}
+	if sz > maxMetadataLen {
+		return ErrMetadataTooLarge
+	}
+	md = maps.Clone(md)
+	switch x := vec.(type) {
+	case *vector:
+		if x.ro {
+			return ErrFrozen
+		}
+		x.md = md
+	case *concurrentVector:
+		if x.frozen() {
+			return ErrFrozen
+		}
+		x.md = md
+	case *roaringVector:
+		if x.ro {
+			return ErrFrozen
+		}
+		x.md = md
+	case *frozenVector:
+		return ErrFrozen
+	case *Journal:
+		return x.apply(func() error { return SetMetadata(x.vec, md) })
+	default:
+		return ErrWrongType
+	}
+	return nil
+}
+
+// MetadataOf returns metadata of the vector or nil if the vector has no metadata.
+//
+// Returned map must not be modified, use SetMetadata instead.
+func MetadataOf(vec Interface) Metadata {
+	switch x := unwrapJournal(vec).(type) {
+	case *vector:
+		return x.md
+	case *frozenVector:
+		return x.src.md
+	case *concurrentVector:
+		return x.md
+	case *roaringVector:
+		return x.md
+	default:
+		return nil
+	}
+}
+
+// appendMetadata appends encoded metadata block to dst.
+//
+// Block consists of 4-byte length of entries, entries in order of keys and zero padding to multiple of 8 bytes, so
+// the payload following the header stays aligned. Entry consists of 2-byte key length, key, 4-byte value length and
+// value.
+func appendMetadata(dst []byte, md Metadata) []byte {
+	off := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		dst = binary.LittleEndian.AppendUint16(dst, uint16(len(k)))
+		dst = append(dst, k...)
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(md[k])))
+		dst = append(dst, md[k]...)
+	}
+	binary.LittleEndian.PutUint32(dst[off:], uint32(len(dst)-off-4))
+	for (len(dst)-off)%8 != 0 {
+		dst = append(dst, 0)
+	}
+	return dst
+}
+
+// readMetadata reads metadata block and returns metadata and length of the block.
+func readMetadata(r io.Reader) (Metadata, int, error) {
+	var buf [4]byte
+	if _, err := readFull(r, buf[:]); err != nil {
+		return nil, 0, err
+	}
+	ln := int(binary.LittleEndian.Uint32(buf[:]))
+	if ln > maxMetadataLen {
+		return nil, 0, ErrCorrupted
+	}
+	n := 4 + ln
+	n += (8 - n%8) % 8
+	p, err := readSlice[uint8](r, nil, uint64(n-4))
+	if err != nil {
+		return nil, 0, err
+	}
+	md, p := make(Metadata), p[:ln]
+	for len(p) > 0 {
+		if len(p) < 2 {
+			return nil, 0, ErrCorrupted
+		}
+		kl := int(binary.LittleEndian.Uint16(p))
+		if len(p) < 2+kl+4 {
+			return nil, 0, ErrCorrupted
+		}
+		k := string(p[2 : 2+kl])
+		p = p[2+kl:]
+		vl := int(binary.LittleEndian.Uint32(p))
+		if len(p) < 4+vl {
+			return nil, 0, ErrCorrupted
+		}
+		md[k] = string(p[4 : 4+vl])
+		p = p[4+vl:]
+	}
+	if len(md) == 0 {
+		md = nil
+	}
+	return md, n, nil
+}
@@ -0,0 +1,87 @@
+package bitvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	md := Metadata{"created": "2024-05-01T10:00:00Z", "job": "daily-segments", "segment": "eu-west"}
+	t.Run("dump", func(t *testing.T) {
+		for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+			vec, _ := Parse("3,5,7-9,100", kind)
+			if err := SetMetadata(vec, md); err != nil {
+				t.Fatal(err)
+			}
+			// Metadata must survive clone and dump.
+			var buf bytes.Buffer
+			if _, err := vec.Clone().WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			h, err := Inspect(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(h.Metadata, md) || h.Flags&FlagMetadata == 0 || h.HeaderLen%8 != 0 {
+				t.Errorf("%s: unexpected header %+v", kind, h)
+			}
+			vec1, err := Load(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(MetadataOf(vec1), md) || Compare(vec, vec1) != 0 {
+				t.Errorf("%s: got %v with metadata %v", kind, vec1, MetadataOf(vec1))
+			}
+		}
+	})
+	t.Run("copy", func(t *testing.T) {
+		src := Metadata{"job": "a"}
+		vec, _ := NewVector(10)
+		_ = SetMetadata(vec, src)
+		src["job"] = "b"
+		if MetadataOf(vec)["job"] != "a" {
+			t.Error("metadata must be copied")
+		}
+		_ = SetMetadata(vec, Metadata{})
+		var buf bytes.Buffer
+		_, _ = vec.WriteTo(&buf)
+		if h, _ := Inspect(&buf); MetadataOf(vec) != nil || h.Flags&FlagMetadata != 0 {
+			t.Error("metadata must be removed")
+		}
+	})
+	t.Run("frozen", func(t *testing.T) {
+		vec, _ := NewConcurrentVector(10, 0)
+		_ = SetMetadata(vec, md)
+		frozen := vec.(Freezer).Freeze()
+		if err := SetMetadata(frozen, nil); err != ErrFrozen {
+			t.Errorf("expected frozen error, got %v", err)
+		}
+		if !reflect.DeepEqual(MetadataOf(frozen), md) {
+			t.Errorf("unexpected metadata %v", MetadataOf(frozen))
+		}
+	})
+	t.Run("too large", func(t *testing.T) {
+		vec, _ := NewVector(10)
+		if err := SetMetadata(vec, Metadata{strings.Repeat("k", 1<<16): ""}); err != ErrMetadataTooLarge {
+			t.Errorf("expected too large error, got %v", err)
+		}
+		if err := SetMetadata(vec, Metadata{"k": strings.Repeat("v", maxMetadataLen)}); err != ErrMetadataTooLarge {
+			t.Errorf("expected too large error, got %v", err)
+		}
+	})
+	t.Run("corrupted", func(t *testing.T) {
+		vec, _ := NewVector(10)
+		_ = SetMetadata(vec, Metadata{"k": "v"})
+		var buf bytes.Buffer
+		_, _ = vec.WriteTo(&buf)
+		p := buf.Bytes()
+		// Value length exceeds the block.
+		binary.LittleEndian.PutUint32(p[40+4+2+1:], 100)
+		if _, err := Load(bytes.NewReader(p)); err != ErrCorrupted {
+			t.Errorf("expected corrupted error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package bitvector
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+// mapDump makes read-only vector over the dump p without copying the payload.
+//
+// Only plain dumps of vector and concurrent vector can be mapped. The checksum isn't verified, since it would require
+// reading the whole payload.
+func mapDump(p []byte) (Interface, error) {
+	h, err := readHeader(bytes.NewReader(p), 0)
+	if err != nil {
+		return nil, err
+	}
+	if h.flags&(dumpFlagCompressed|dumpFlagBlocks) != 0 {
+		return nil, ErrNotMappable
+	}
+	c, s, payload := h.fields[0], h.fields[1], p[h.len:]
+	switch h.kind {
+	case KindVector:
+		n := c/8 + 1
+		if uint64(len(payload)) < n {
+			return nil, ErrTruncated
+		}
+		vec := &vector{buf: payload[:n:n], c: c, s: s, ro: true, md: h.md}
+		if h.flags&vectorFlagMSB != 0 {
+			vec.o = MSBFirst.mask()
+		}
+		return vec, nil
+	case KindConcurrentVector:
+		// Payload consists of little-endian words and is 8-byte aligned within the dump.
+		if !hostLE {
+			return nil, ErrNotMappable
+		}
+		n := c/32 + 1
+		if uint64(len(payload)) < n*4 {
+			return nil, ErrTruncated
+		}
+		vec := &concurrentVector{
+			buf: unsafe.Slice((*uint32)(unsafe.Pointer(&payload[0])), n),
+			lim: h.fields[2],
+			c:   c,
+			s:   s,
+			md:  h.md,
+		}
+		return vec.Freeze(), nil
+	default:
+		return nil, ErrNotMappable
+	}
+}
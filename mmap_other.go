@@ -0,0 +1,46 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package bitvector
+
+import (
+	"io"
+	"os"
+)
+
+// OpenMmap reads the dump file and returns read-only vector over it.
+//
+// Memory mapping isn't supported on this platform, so the file is read into memory. Only plain (neither compressed
+// nor block encoded) dumps of vector and concurrent vector are accepted, otherwise ErrNotMappable returns.
+func OpenMmap(path string) (Interface, io.Closer, error) {
+	p, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	vec, err := mapDump(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vec, io.NopCloser(nil), nil
+}
+
+// mapFile reads first size bytes of the file into memory.
+func mapFile(f *os.File, size int) ([]byte, error) {
+	p := make([]byte, size)
+	if _, err := f.ReadAt(p, 0); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// syncFile writes the memory back to the file and flushes it.
+func syncFile(f *os.File, p []byte) error {
+	if _, err := f.WriteAt(p, 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// unmapFile does nothing, since the memory is released by GC.
+func unmapFile([]byte) error {
+	return nil
+}
@@ -0,0 +1,105 @@
+package bitvector
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpenMmap(t *testing.T) {
+	dump := func(t *testing.T, vec Interface, write func(*os.File, Interface) error) string {
+		path := filepath.Join(t.TempDir(), "vector.bin")
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = f.Close() }()
+		if err = write(f, vec); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	writeTo := func(f *os.File, vec Interface) error {
+		_, err := vec.WriteTo(f)
+		return err
+	}
+	md := Metadata{"segment": "eu-west"}
+	for _, kind := range []Kind{KindVector, KindConcurrentVector} {
+		t.Run(kind.String(), func(t *testing.T) {
+			vec, _ := Parse("3,5,7-9,100,1000-1100", kind)
+			_ = SetMetadata(vec, md)
+			vec1, c, err := OpenMmap(dump(t, vec, writeTo))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = c.Close() }()
+			if Compare(vec, vec1) != 0 || vec1.Popcnt() != vec.Popcnt() || vec1.Get(1050) != 1 || vec1.Get(101) != 0 {
+				t.Errorf("got %v, want %v", vec1, vec)
+			}
+			if !reflect.DeepEqual(MetadataOf(vec1), md) {
+				t.Errorf("unexpected metadata %v", MetadataOf(vec1))
+			}
+			if vec1.Set(10) || vec1.Unset(3) || vec1.Get(10) != 0 {
+				t.Error("mapped vector must be read-only")
+			}
+			clone := vec1.Clone()
+			if !clone.Set(10) || clone.Get(10) != 1 || vec1.Get(10) != 0 {
+				t.Error("clone must be mutable copy")
+			}
+		})
+	}
+	t.Run("msb", func(t *testing.T) {
+		vec, _ := NewVectorWithOrder(64, MSBFirst)
+		vec.Set(1)
+		vec1, c, err := OpenMmap(dump(t, vec, writeTo))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = c.Close() }()
+		if vec1.Get(1) != 1 || bytesOf(vec1)[0] != 0x40 {
+			t.Errorf("unexpected bytes %v", bytesOf(vec1))
+		}
+	})
+	t.Run("size counter", func(t *testing.T) {
+		vec, _ := NewVector(64)
+		vec.Unset(3)
+		vec1, c, err := OpenMmap(dump(t, vec, writeTo))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = c.Close() }()
+		if vec1.Size() != vec.Size() || vec1.Popcnt() != 0 {
+			t.Errorf("got %v of size %d", vec1, vec1.Size())
+		}
+	})
+	t.Run("not mappable", func(t *testing.T) {
+		vec, _ := Parse("3,5,7-9", KindVector)
+		rvec, _ := Parse("3,5,7-9", KindRoaringVector)
+		stages := []struct {
+			vec   Interface
+			write func(*os.File, Interface) error
+		}{
+			{vec, func(f *os.File, vec Interface) error { _, err := WriteCompressed(f, vec); return err }},
+			{vec, func(f *os.File, vec Interface) error { _, err := WriteAdaptive(f, vec); return err }},
+			{rvec, writeTo},
+		}
+		for i, st := range stages {
+			if _, _, err := OpenMmap(dump(t, st.vec, st.write)); !errors.Is(err, ErrNotMappable) {
+				t.Errorf("stage %d: expected not mappable error, got %v", i, err)
+			}
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		vec, _ := Parse("3,5,7-9,1000", KindConcurrentVector)
+		path := dump(t, vec, writeTo)
+		fi, _ := os.Stat(path)
+		if err := os.Truncate(path, fi.Size()-64); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := OpenMmap(path); !errors.Is(err, ErrTruncated) {
+			t.Errorf("expected truncated error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package bitvector
+
+import (
+	"io"
+	"math"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// OpenMmap maps the dump file to memory and returns read-only vector over it.
+//
+// Get, Popcnt, iteration and set operations are served directly from the page cache without copying, so several
+// processes share the same memory. Only plain (neither compressed nor block encoded) dumps of vector and concurrent
+// vector can be mapped, otherwise ErrNotMappable returns. Use Clone to get mutable copy of the vector.
+//
+// The vector must not be used after closing the returned closer.
+func OpenMmap(path string) (Interface, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = f.Close() }()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi.Size() == 0 {
+		return nil, nil, ErrTruncated
+	}
+	if fi.Size() > math.MaxInt {
+		return nil, nil, ErrNotMappable
+	}
+	p, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	vec, err := mapDump(p)
+	if err != nil {
+		_ = syscall.Munmap(p)
+		return nil, nil, err
+	}
+	return vec, &mapping{p: p}, nil
+}
+
+// mapping unmaps the memory on close.
+type mapping struct {
+	p []byte
+}
+
+func (m *mapping) Close() error {
+	if m.p == nil {
+		return nil
+	}
+	err := syscall.Munmap(m.p)
+	m.p = nil
+	return err
+}
+
+// mapFile maps first size bytes of the file to memory with write access.
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+}
+
+// syncFile flushes modified pages of mapped file.
+func syncFile(_ *os.File, p []byte) error {
+	_, _, errno := syscall.Syscall(sysMsync, uintptr(unsafe.Pointer(&p[0])), uintptr(len(p)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// unmapFile unmaps the memory of mapped file.
+func unmapFile(p []byte) error {
+	return syscall.Munmap(p)
+}
@@ -0,0 +1,4 @@
+package bitvector
+
+// sysMsync is the number of __msync13 system call, which is missing in syscall package.
+const sysMsync = 277
@@ -0,0 +1,7 @@
+//go:build darwin || dragonfly || freebsd || linux || openbsd
+
+package bitvector
+
+import "syscall"
+
+const sysMsync = syscall.SYS_MSYNC
@@ -0,0 +1,182 @@
+// Package parquet implements Parquet RLE/bit-packing hybrid encoding of vectors.
+//
+// The encoding is used by Parquet for boolean columns (RLE encoding) and for definition levels. Since vector stores one
+// bit per value, bit width of encoded values is always 1.
+package parquet
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/koykov/bitvector"
+)
+
+const (
+	// BitWidth is the bit width of values of the encoding.
+	BitWidth = 1
+
+	// minRLEBytes is the minimal number of repeated bytes (8 values each) encoded as RLE run.
+	minRLEBytes = 2
+)
+
+// Encoder writes vectors in RLE/bit-packing hybrid encoding.
+type Encoder struct {
+	// LengthPrefix enables 4-byte little-endian length before encoded data, as required by data pages.
+	LengthPrefix bool
+
+	raw, buf []byte
+}
+
+// Encode writes first n bits of vec to w. Values exceeding vector's capacity are encoded as zeros.
+func (e *Encoder) Encode(w io.Writer, vec bitvector.Interface, n uint64) (int64, error) {
+	nb := int((n + 7) / 8)
+	e.raw = bitvector.ExportBytes(e.raw[:0], vec, bitvector.LSBFirst)
+	for len(e.raw) < nb {
+		e.raw = append(e.raw, 0)
+	}
+	raw := e.raw[:nb]
+	if n%8 != 0 {
+		raw[nb-1] &= ^(uint8(0xff) << (n % 8))
+	}
+
+	e.buf = e.buf[:0]
+	if e.LengthPrefix {
+		e.buf = append(e.buf, 0, 0, 0, 0)
+	}
+	var lit int // start of pending literal bytes
+	full := int(n / 8)
+	for i := 0; i < full; {
+		b := raw[i]
+		j := i + 1
+		if b == 0 || b == 0xff {
+			for j < full && raw[j] == b {
+				j++
+			}
+		}
+		if j-i < minRLEBytes {
+			i = j
+			continue
+		}
+		e.buf = appendBitPacked(e.buf, raw[lit:i])
+		e.buf = binary.AppendUvarint(e.buf, uint64(j-i)*8<<1)
+		e.buf = append(e.buf, b&1)
+		i, lit = j, j
+	}
+	e.buf = appendBitPacked(e.buf, raw[lit:])
+	if e.LengthPrefix {
+		binary.LittleEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+	}
+	m, err := w.Write(e.buf)
+	return int64(m), err
+}
+
+// appendBitPacked appends bit-packed run of given groups of 8 values.
+func appendBitPacked(dst, groups []byte) []byte {
+	if len(groups) == 0 {
+		return dst
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(groups))<<1|1)
+	return append(dst, groups...)
+}
+
+// Decoder reads vectors in RLE/bit-packing hybrid encoding.
+type Decoder struct {
+	// LengthPrefix indicates that encoded data is prefixed with 4-byte little-endian length.
+	LengthPrefix bool
+}
+
+// Decode reads n values from r and returns them as a vector.
+//
+// Data following the encoded values isn't consumed, so r may be read further by the caller.
+func (d *Decoder) Decode(r io.Reader, n uint64) (bitvector.Interface, error) {
+	if d.LengthPrefix {
+		var pfx [4]byte
+		if _, err := io.ReadFull(r, pfx[:]); err != nil {
+			return nil, err
+		}
+		r = io.LimitReader(r, int64(binary.LittleEndian.Uint32(pfx[:])))
+	}
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = &byteReader{r: r}
+	}
+
+	buf := make([]byte, n/8+1)
+	var grp [256]byte
+	for pos := uint64(0); pos < n; {
+		h, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if h&1 == 0 {
+			// RLE run: number of repetitions followed by the value.
+			var v byte
+			if v, err = br.ReadByte(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			cnt := min(h>>1, n-pos)
+			if v&1 != 0 {
+				fill(buf, pos, pos+cnt)
+			}
+			pos += cnt
+			continue
+		}
+		// Bit-packed run: number of groups of 8 values followed by packed values.
+		for groups := h >> 1; groups > 0; {
+			k := min(groups, uint64(len(grp)))
+			if _, err = io.ReadFull(r, grp[:k]); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			for i := uint64(0); i < k && pos < n; i++ {
+				put(buf, pos, grp[i])
+				pos += 8
+			}
+			groups -= k
+		}
+	}
+	// Clear padding bits of the last group.
+	buf[n/8] &= ^(uint8(0xff) << (n % 8))
+	return bitvector.FromBytes(buf, n)
+}
+
+// fill sets bits in range [lo, hi).
+func fill(buf []byte, lo, hi uint64) {
+	for ; lo < hi && lo%8 != 0; lo++ {
+		buf[lo/8] |= 1 << (lo % 8)
+	}
+	for ; lo+8 <= hi; lo += 8 {
+		buf[lo/8] = 0xff
+	}
+	for ; lo < hi; lo++ {
+		buf[lo/8] |= 1 << (lo % 8)
+	}
+}
+
+// put writes 8 bits of b starting from position pos.
+func put(buf []byte, pos uint64, b byte) {
+	i, s := pos/8, pos%8
+	buf[i] |= b << s
+	if s > 0 && i+1 < uint64(len(buf)) {
+		buf[i+1] |= b >> (8 - s)
+	}
+}
+
+// byteReader reads one byte per call from the underlying reader, unlike bufio.Reader it doesn't read ahead.
+type byteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (r *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(r.r, r.buf[:]); err != nil {
+		return 0, err
+	}
+	return r.buf[0], nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
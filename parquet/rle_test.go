@@ -0,0 +1,124 @@
+package parquet
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/koykov/bitvector"
+)
+
+func TestRLE(t *testing.T) {
+	t.Run("encode", func(t *testing.T) {
+		stages := []struct {
+			pos  string
+			n    uint64
+			want []byte
+		}{
+			{"0-15", 16, []byte{0x20, 0x01}},
+			{"0,4,5,7", 8, []byte{0x03, 0xb1}},
+			{"0,4,5,7", 40, []byte{0x03, 0xb1, 0x40, 0x00}},
+			{"0-31,33", 35, []byte{0x40, 0x01, 0x03, 0x02}},
+		}
+		for _, st := range stages {
+			vec, _ := bitvector.Parse(st.pos, bitvector.KindVector)
+			var buf bytes.Buffer
+			var enc Encoder
+			if _, err := enc.Encode(&buf, vec, st.n); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), st.want) {
+				t.Errorf("%s: got %x, want %x", st.pos, buf.Bytes(), st.want)
+			}
+		}
+	})
+	t.Run("decode", func(t *testing.T) {
+		// RLE run of 5 ones, bit-packed group 0b10, RLE run of 3 zeros, RLE run of 4 ones.
+		data := []byte{0x0a, 0x01, 0x03, 0x02, 0x06, 0x00, 0x08, 0x01}
+		var dec Decoder
+		vec, err := dec.Decode(bytes.NewReader(data), 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := bitvector.Parse("0-4,6,16-19", bitvector.KindVector)
+		if bitvector.Compare(vec, want) != 0 {
+			t.Errorf("got %v, want %v", vec, want)
+		}
+		if _, err = dec.Decode(bytes.NewReader(data[:3]), 20); err == nil {
+			t.Error("expected error on truncated data")
+		}
+		// Reader without ReadByte must not be read beyond the encoded values.
+		r := bytes.NewReader(append(data[:len(data):len(data)], "tail"...))
+		if _, err = dec.Decode(struct{ io.Reader }{r}, 20); err != nil {
+			t.Fatal(err)
+		}
+		if tail, _ := io.ReadAll(r); string(tail) != "tail" {
+			t.Errorf("unexpected data after decoding %q", tail)
+		}
+	})
+	t.Run("round trip", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(1))
+		for _, n := range []uint64{1, 7, 8, 100, 1000, 10007} {
+			for _, pfx := range []bool{false, true} {
+				vec, _ := bitvector.NewVector(n)
+				for i := uint64(0); i < n; {
+					// Mix of dense runs and noise.
+					k := uint64(rnd.Intn(100) + 1)
+					switch rnd.Intn(3) {
+					case 0:
+						vec.(bitvector.RangeSetter).SetRange(i, min(i+k, n))
+					case 1:
+						for j := i; j < min(i+k, n); j++ {
+							if rnd.Intn(2) == 0 {
+								vec.Set(j)
+							}
+						}
+					}
+					i += k
+				}
+				var buf bytes.Buffer
+				enc := Encoder{LengthPrefix: pfx}
+				if _, err := enc.Encode(&buf, vec, n); err != nil {
+					t.Fatal(err)
+				}
+				buf.WriteString("tail")
+				dec := Decoder{LengthPrefix: pfx}
+				vec1, err := dec.Decode(&buf, n)
+				if err != nil {
+					t.Fatalf("n %d: %v", n, err)
+				}
+				if bitvector.Compare(vec, vec1) != 0 {
+					t.Errorf("n %d: round trip mismatch", n)
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkRLE(b *testing.B) {
+	vec, _ := bitvector.NewVector(1e5)
+	for i := uint64(0); i < 1e5; i += 1000 {
+		vec.(bitvector.RangeSetter).SetRange(i, i+500)
+		vec.Set(i + 700)
+	}
+	var (
+		buf bytes.Buffer
+		enc Encoder
+		dec Decoder
+	)
+	b.Run("encode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			_, _ = enc.Encode(&buf, vec, 1e5)
+		}
+	})
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		data := buf.Bytes()
+		for i := 0; i < b.N; i++ {
+			_, _ = dec.Decode(bytes.NewReader(data), 1e5)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package bitvector
+
+import (
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxParseSize is the greatest size of the vector Parse may make. Roaring vector isn't preallocated, so for it the
+// limit applies to the number of bits in the ranges instead. It keeps hostile input from causing huge allocations.
+const MaxParseSize = 1 << 30
+
+// ParseError describes a problem of parsing vector's textual representation.
+type ParseError struct {
+	// Offset of the problem in the input string.
+	Offset int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return "bitvector: syntax error at offset " + strconv.Itoa(e.Offset) + ": " + e.Msg
+}
+
+// Parse makes new vector of given kind from its textual representation.
+//
+// Supported formats (see %v, %#b and %#x verbs of vector's formatting):
+//   - list of positions and inclusive ranges, optionally enclosed with braces: "1,3-7,100" or "{1,3-7,100}"
+//   - bit string prefixed with 0b, bit at position 0 goes first: "0b0101"
+//   - hex string prefixed with 0x, bytes in memory order: "0xa802"
+//
+// Spaces and underscores are allowed in bit and hex strings. Size of the vector is the length of bit/hex string or the
+// greatest position of the list plus one, it can't exceed MaxParseSize. ErrWriteFailed returns if the bits can't be
+// written to the vector.
+func Parse(s string, kind Kind) (Interface, error) {
+	var (
+		rs   []prange
+		size uint64
+		err  error
+	)
+	switch {
+	case strings.HasPrefix(s, "0b") || strings.HasPrefix(s, "0B"):
+		rs, size, err = parseBits(s, 2)
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+		rs, size, err = parseHex(s, 2)
+	default:
+		rs, size, err = parseRanges(s, kind == KindRoaringVector)
+	}
+	if err != nil {
+		return nil, err
+	}
+	vec, err := newOfKind(kind, size, defaultWriteAttemptsLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rs {
+		if !setRange(vec, r.lo, r.hi) {
+			return nil, ErrWriteFailed
+		}
+	}
+	return vec, nil
+}
+
+// prange represents half-open range of positions [lo, hi).
+type prange struct {
+	lo, hi uint64
+}
+
+func parseBits(s string, off int) (rs []prange, size uint64, err error) {
+	for i := off; i < len(s); i++ {
+		if size == MaxParseSize && (s[i] == '0' || s[i] == '1') {
+			return nil, 0, &ParseError{Offset: i, Msg: "vector is too large"}
+		}
+		switch s[i] {
+		case '0':
+			size++
+		case '1':
+			if n := len(rs); n > 0 && rs[n-1].hi == size {
+				rs[n-1].hi++
+			} else {
+				rs = append(rs, prange{lo: size, hi: size + 1})
+			}
+			size++
+		case '_', ' ':
+		default:
+			return nil, 0, &ParseError{Offset: i, Msg: "unexpected character " + strconv.QuoteRune(rune(s[i]))}
+		}
+	}
+	return
+}
+
+func parseHex(s string, off int) (rs []prange, size uint64, err error) {
+	var (
+		pair [2]byte
+		b    [1]byte
+		n    int
+	)
+	for i := off; i < len(s); i++ {
+		if s[i] == '_' || s[i] == ' ' {
+			continue
+		}
+		if size == MaxParseSize {
+			return nil, 0, &ParseError{Offset: i, Msg: "vector is too large"}
+		}
+		pair[n] = s[i]
+		if n++; n < 2 {
+			continue
+		}
+		n = 0
+		if _, err = hex.Decode(b[:], pair[:]); err != nil {
+			return nil, 0, &ParseError{Offset: i - 1, Msg: "invalid hex byte " + strconv.Quote(string(pair[:]))}
+		}
+		for j := uint64(0); j < 8; j++ {
+			if b[0]&(1<<j) == 0 {
+				continue
+			}
+			if k := len(rs); k > 0 && rs[k-1].hi == size+j {
+				rs[k-1].hi++
+			} else {
+				rs = append(rs, prange{lo: size + j, hi: size + j + 1})
+			}
+		}
+		size += 8
+	}
+	if n != 0 {
+		return nil, 0, &ParseError{Offset: len(s), Msg: "odd number of hex digits"}
+	}
+	return
+}
+
+func parseRanges(s string, sparse bool) (rs []prange, size uint64, err error) {
+	var n uint64
+	lo, hi := 0, len(s)
+	if t := strings.TrimSpace(s); strings.HasPrefix(t, "{") {
+		lo = strings.IndexByte(s, '{') + 1
+		if hi = strings.LastIndexByte(s, '}'); hi < lo || strings.TrimSpace(s[hi+1:]) != "" {
+			return nil, 0, &ParseError{Offset: len(s), Msg: "missing closing brace"}
+		}
+	}
+	if strings.TrimSpace(s[lo:hi]) == "" {
+		return
+	}
+	for i := lo; i <= hi; {
+		j := i + strings.IndexByte(s[i:hi], ',')
+		if j < i {
+			j = hi
+		}
+		var r prange
+		if r, err = parseRange(s[i:j], i); err != nil {
+			return nil, 0, err
+		}
+		if n += r.hi - r.lo; !sparse && r.hi > MaxParseSize || sparse && n > MaxParseSize {
+			off := i + len(s[i:j]) - len(strings.TrimLeft(s[i:j], " "))
+			return nil, 0, &ParseError{Offset: off, Msg: "vector is too large"}
+		}
+		rs = append(rs, r)
+		size = max(size, r.hi)
+		i = j + 1
+	}
+	return
+}
+
+func parseRange(s string, off int) (r prange, err error) {
+	parseUint := func(s string, off int) (uint64, error) {
+		t := strings.TrimLeft(s, " ")
+		off += len(s) - len(t)
+		t = strings.TrimRight(t, " ")
+		if len(t) == 0 {
+			return 0, &ParseError{Offset: off, Msg: "missing position"}
+		}
+		x, err := strconv.ParseUint(t, 10, 64)
+		if err != nil {
+			return 0, &ParseError{Offset: off, Msg: "invalid position " + strconv.Quote(t)}
+		}
+		return x, nil
+	}
+	// Offset of the upper bound for error reporting.
+	hs, hoff := s, off
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if r.lo, err = parseUint(s[:i], off); err != nil {
+			return
+		}
+		hs, hoff = s[i+1:], off+i+1
+		if r.hi, err = parseUint(hs, hoff); err != nil {
+			return
+		}
+		if r.lo > r.hi {
+			err = &ParseError{Offset: off, Msg: "range bounds are reversed"}
+			return
+		}
+	} else if r.lo, err = parseUint(s, off); err != nil {
+		return
+	} else {
+		r.hi = r.lo
+	}
+	// Range is half-open, so the greatest position can't be represented.
+	if r.hi == math.MaxUint64 {
+		err = &ParseError{Offset: hoff + len(hs) - len(strings.TrimLeft(hs, " ")), Msg: "position is out of range"}
+		return
+	}
+	r.hi++
+	return
+}
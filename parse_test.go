@@ -0,0 +1,113 @@
+package bitvector
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("formats", func(t *testing.T) {
+		stages := []struct {
+			s    string
+			size uint64
+			want string
+		}{
+			{"1,3-7,100", 101, "{1,3-7,100}"},
+			{" { 3, 5 , 7-9 } ", 10, "{3,5,7-9}"},
+			{"{}", 0, "{}"},
+			{"0b0001_0101_1100", 12, "{3,5,7-9}"},
+			{"0xa803 0000", 32, "{3,5,7-9}"},
+			{"0XFF01", 16, "{0-8}"},
+		}
+		for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+			for _, st := range stages {
+				vec, err := Parse(st.s, kind)
+				if st.size == 0 && kind != KindRoaringVector {
+					if err != ErrZeroSize {
+						t.Errorf("%s %q: expected zero size error, got %v", kind, st.s, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("%s %q: %v", kind, st.s, err)
+					continue
+				}
+				if r := fmt.Sprintf("%v", vec); r != st.want {
+					t.Errorf("%s %q: got %s, want %s", kind, st.s, r, st.want)
+				}
+				if kind == KindRoaringVector {
+					continue
+				}
+				if vec.Capacity() < st.size {
+					t.Errorf("%s %q: capacity %d is less than %d", kind, st.s, vec.Capacity(), st.size)
+				}
+			}
+		}
+	})
+	t.Run("round trip", func(t *testing.T) {
+		vec, _ := NewVector(200)
+		for _, p := range []uint64{0, 1, 2, 63, 64, 65, 130, 199} {
+			vec.Set(p)
+		}
+		for _, format := range []string{"%v", "%#b", "%#x"} {
+			s := fmt.Sprintf(format, vec)
+			vec1, err := Parse(s, KindVector)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if Compare(vec, vec1) != 0 {
+				t.Errorf("%s: %v != %v", format, vec, vec1)
+			}
+		}
+	})
+	t.Run("limit", func(t *testing.T) {
+		for _, kind := range []Kind{KindVector, KindConcurrentVector} {
+			_, err := Parse("1, 18446744073709551614", kind)
+			var perr *ParseError
+			if !errors.As(err, &perr) || perr.Offset != 3 {
+				t.Errorf("%s: expected parse error at offset 3, got %v", kind, err)
+			}
+		}
+		vec, err := Parse("1, 18446744073709551614", KindRoaringVector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vec.Get(18446744073709551614) != 1 {
+			t.Error("sparse position must be set")
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		stages := []struct {
+			s   string
+			off int
+		}{
+			{"1,,3", 2},
+			{"1, x", 3},
+			{"5-3", 0},
+			{"1,3-", 4},
+			{"{1,2", 4},
+			{"0b0120", 4},
+			{"0xa8z3", 4},
+			{"0xa80", 5},
+			{"18446744073709551615", 0},
+			{"1, 18446744073709551615", 3},
+			{"1,5- 18446744073709551615", 5},
+			{"1, 0-4294967296", 3},
+			{"0-1073741823,1073741824", 13},
+		}
+		for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+			for _, st := range stages {
+				_, err := Parse(st.s, kind)
+				var perr *ParseError
+				if !errors.As(err, &perr) {
+					t.Errorf("%s %q: expected parse error, got %v", kind, st.s, err)
+					continue
+				}
+				if perr.Offset != st.off {
+					t.Errorf("%s %q: got offset %d, want %d (%v)", kind, st.s, perr.Offset, st.off, err)
+				}
+			}
+		}
+	})
+}
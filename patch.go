@@ -0,0 +1,228 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"hash/crc64"
+	"io"
+	"math"
+	"math/bits"
+)
+
+const (
+	patchSignature = 0x48435441505654b2
+	patchVersion   = 1
+)
+
+var patchTable = crc64.MakeTable(crc64.ISO)
+
+// Patch represents changes between two vectors, see Diff and Apply.
+//
+// Changes are stored as XOR of changed 64-bit words, so patch size depends on number of changed words only. Base and
+// target vectors are identified by fingerprints of their contents, which don't depend on vector kind.
+type Patch struct {
+	base, target uint64
+	// Indices of changed words in ascending order and XOR of old and new words.
+	idx, xor []uint64
+}
+
+// Diff returns patch converting old vector to new one.
+func Diff(old, new Interface) Patch {
+	var p Patch
+	mergeWords(old, new, func(i, x, y uint64) bool {
+		if x != y {
+			p.idx = append(p.idx, i)
+			p.xor = append(p.xor, x^y)
+		}
+		return true
+	})
+	p.base, p.target = fingerprint(old), fingerprint(new)
+	return p
+}
+
+// Apply applies patch to the vector.
+//
+// ErrPatchMismatch returns if the vector differs from the base vector of patch, ErrShortBuffer returns if changes
+// don't fit the vector capacity and ErrFrozen returns if the vector is read-only. In these cases the vector stays
+// unchanged. If a bit can't be written midway, e.g. write attempts limit of concurrent vector is exceeded, already
+// applied changes are rolled back as far as possible and ErrWriteFailed returns. Journal which failed to log a change
+// keeps the changes logged before and returns its error (see Journal.Err). ErrCorrupted returns if the result differs
+// from the target vector of patch.
+func Apply(vec Interface, p Patch) error {
+	if j, ok := vec.(*Journal); ok {
+		if err := j.Err(); err != nil {
+			return err
+		}
+	}
+	if fingerprint(vec) != p.base {
+		return ErrPatchMismatch
+	}
+	if len(p.idx) == 0 {
+		return nil
+	}
+	if isFrozen(vec) {
+		return ErrFrozen
+	}
+	if _, ok := unwrapJournal(vec).(*roaringVector); !ok {
+		i := len(p.idx) - 1
+		if p.idx[i]*64+uint64(63-bits.LeadingZeros64(p.xor[i])) >= vec.Capacity() {
+			return ErrShortBuffer
+		}
+	}
+	for i, x := range p.idx {
+		for w := p.xor[i]; w != 0; w &= w - 1 {
+			if !flip(vec, x*64+uint64(bits.TrailingZeros64(w))) {
+				return rollback(vec, p, i, w)
+			}
+		}
+	}
+	recount(unwrapJournal(vec))
+	if fingerprint(vec) != p.target {
+		return ErrCorrupted
+	}
+	return nil
+}
+
+// rollback reverts changes of patch applied before the failed write of i-th word, which bits w remain unapplied, and
+// returns the failure cause.
+func rollback(vec Interface, p Patch, i int, w uint64) error {
+	if j, ok := vec.(*Journal); ok {
+		// Failed journal rejects any writes, so the vector stays consistent with the logged changes.
+		if err := j.Err(); err != nil {
+			return err
+		}
+	}
+	undo := func(x, w uint64) {
+		for ; w != 0; w &= w - 1 {
+			flip(vec, x*64+uint64(bits.TrailingZeros64(w)))
+		}
+	}
+	undo(p.idx[i], p.xor[i]&^w)
+	for k := i - 1; k >= 0; k-- {
+		undo(p.idx[k], p.xor[k])
+	}
+	recount(unwrapJournal(vec))
+	return ErrWriteFailed
+}
+
+// isFrozen checks if the vector is read-only.
+func isFrozen(vec Interface) bool {
+	switch x := vec.(type) {
+	case *vector:
+		return x.ro
+	case *frozenVector:
+		return true
+	case *concurrentVector:
+		return x.frozen()
+	case *roaringVector:
+		return x.ro
+	case *Journal:
+		return isFrozen(x.vec)
+	default:
+		return false
+	}
+}
+
+// flip inverts the bit at given position.
+func flip(vec Interface, i uint64) bool {
+	if vec.Xor(i) {
+		return true
+	}
+	// Roaring vector doesn't support Xor.
+	if vec.Get(i) != 0 {
+		return vec.Unset(i)
+	}
+	return vec.Set(i)
+}
+
+// fingerprint returns CRC64 of indices and values of non-zero words of the vector.
+func fingerprint(vec Interface) uint64 {
+	var (
+		buf [16]byte
+		crc uint64
+	)
+	it := newWordIter(vec)
+	for i, w, ok := it.next(); ok; i, w, ok = it.next() {
+		binary.LittleEndian.PutUint64(buf[0:], i)
+		binary.LittleEndian.PutUint64(buf[8:], w)
+		crc = crc64.Update(crc, patchTable, buf[:])
+	}
+	return crc
+}
+
+// Empty checks if patch contains no changes.
+func (p *Patch) Empty() bool {
+	return len(p.idx) == 0
+}
+
+// WriteTo writes the patch. Word indices are delta encoded as uvarints followed by 8-byte XOR words.
+func (p *Patch) WriteTo(w io.Writer) (int64, error) {
+	cw := crcWriter{w: w}
+	buf := make([]byte, 48, 48+len(p.idx)*(binary.MaxVarintLen64+8))
+	binary.LittleEndian.PutUint64(buf[0:], patchSignature)
+	binary.LittleEndian.PutUint64(buf[8:], patchVersion)
+	binary.LittleEndian.PutUint64(buf[16:], p.base)
+	binary.LittleEndian.PutUint64(buf[24:], p.target)
+	binary.LittleEndian.PutUint64(buf[32:], uint64(len(p.idx)))
+	var prev uint64
+	for i, x := range p.idx {
+		buf = binary.AppendUvarint(buf, x-prev)
+		buf = binary.LittleEndian.AppendUint64(buf, p.xor[i])
+		prev = x
+	}
+	// Length of encoded changes.
+	binary.LittleEndian.PutUint64(buf[40:], uint64(len(buf)-48))
+	if _, err := cw.Write(buf); err != nil {
+		return cw.n, err
+	}
+	err := cw.writeChecksum()
+	return cw.n, err
+}
+
+// ReadFrom reads the patch written by WriteTo.
+func (p *Patch) ReadFrom(r io.Reader) (n int64, err error) {
+	cr := crcReader{r: r}
+	defer func() { n = cr.n }()
+	var buf [48]byte
+	if _, err = io.ReadFull(&cr, buf[:16]); err != nil {
+		return n, truncated(err)
+	}
+	if binary.LittleEndian.Uint64(buf[0:]) != patchSignature {
+		return n, ErrInvalidSignature
+	}
+	if binary.LittleEndian.Uint64(buf[8:]) != patchVersion {
+		return n, ErrVersionMismatch
+	}
+	if _, err = readFull(&cr, buf[16:]); err != nil {
+		return
+	}
+	cnt, ln := binary.LittleEndian.Uint64(buf[32:]), binary.LittleEndian.Uint64(buf[40:])
+	// Every change takes at least 9 bytes.
+	if cnt > ln/9 || ln > math.MaxInt64 {
+		return n, ErrCorrupted
+	}
+	data, err := readSlice[uint8](&cr, nil, ln)
+	if err != nil {
+		return
+	}
+	idx, xor := make([]uint64, 0, cnt), make([]uint64, 0, cnt)
+	var prev uint64
+	for i := uint64(0); i < cnt; i++ {
+		d, k := binary.Uvarint(data)
+		if k <= 0 || len(data) < k+8 || (i > 0 && d == 0) || prev+d < prev || prev+d > math.MaxUint64/64 {
+			return n, ErrCorrupted
+		}
+		prev += d
+		idx = append(idx, prev)
+		xor = append(xor, binary.LittleEndian.Uint64(data[k:]))
+		data = data[k+8:]
+	}
+	if len(data) != 0 {
+		return n, ErrCorrupted
+	}
+	if err = cr.readChecksum(); err != nil {
+		return
+	}
+	p.base, p.target = binary.LittleEndian.Uint64(buf[16:]), binary.LittleEndian.Uint64(buf[24:])
+	p.idx, p.xor = idx, xor
+	return
+}
@@ -0,0 +1,125 @@
+package bitvector
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+		t.Run(kind.String(), func(t *testing.T) {
+			old, _ := newOfKind(kind, 10000, 0)
+			setRange(old, 100, 200)
+			old.Set(5000)
+			vec := old.Clone()
+			vec1, _ := newOfKind(kind, 10000, 0)
+			setRange(vec1, 100, 150)
+			setRange(vec1, 160, 200)
+			vec1.Set(9000)
+			vec1.Set(9999)
+
+			p := Diff(old, vec1)
+			var buf bytes.Buffer
+			if _, err := p.WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			// Changed words only: 2 words of range, 5000, 9000 and 9999.
+			if buf.Len() > 48+5*10+4 {
+				t.Errorf("patch is too large: %d bytes", buf.Len())
+			}
+			var p1 Patch
+			if _, err := p1.ReadFrom(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if err := Apply(vec, p1); err != nil {
+				t.Fatal(err)
+			}
+			if Compare(vec, vec1) != 0 {
+				t.Errorf("got %v, want %v", vec, vec1)
+			}
+			// Patch can't be applied twice.
+			if err := Apply(vec, p1); !errors.Is(err, ErrPatchMismatch) {
+				t.Errorf("expected patch mismatch error, got %v", err)
+			}
+		})
+	}
+	t.Run("kinds", func(t *testing.T) {
+		old, _ := Parse("1,5,70", KindVector)
+		vec, _ := Parse("1,6,70-80", KindVector)
+		rvec, _ := Parse("1,5,70", KindRoaringVector)
+		if err := Apply(rvec, Diff(old, vec)); err != nil {
+			t.Fatal(err)
+		}
+		if Compare(rvec, vec) != 0 {
+			t.Errorf("got %v, want %v", rvec, vec)
+		}
+		if p := Diff(vec, rvec); !p.Empty() {
+			t.Error("patch between equal vectors must be empty")
+		}
+	})
+	t.Run("capacity", func(t *testing.T) {
+		old, _ := NewVector(64)
+		vec, _ := NewVector(1000)
+		vec.Set(900)
+		if err := Apply(old, Diff(old, vec)); !errors.Is(err, ErrShortBuffer) {
+			t.Errorf("expected short buffer error, got %v", err)
+		}
+	})
+	t.Run("corrupted", func(t *testing.T) {
+		old, _ := NewVector(1000)
+		vec, _ := Parse("3,500-600", KindVector)
+		p := Diff(old, vec)
+		var buf bytes.Buffer
+		_, _ = p.WriteTo(&buf)
+		b := buf.Bytes()
+		for i := 0; i < len(b); i++ {
+			var p1 Patch
+			if _, err := p1.ReadFrom(bytes.NewReader(b[:i])); err == nil {
+				t.Errorf("truncated patch of %d bytes must fail", i)
+			}
+		}
+		b[50] ^= 0xff
+		var p1 Patch
+		if _, err := p1.ReadFrom(bytes.NewReader(b)); err == nil {
+			t.Error("damaged patch must fail")
+		}
+	})
+	t.Run("frozen", func(t *testing.T) {
+		old, _ := Parse("1,5,70", KindConcurrentVector)
+		vec, _ := Parse("1,6,70-80", KindConcurrentVector)
+		frz := old.(Freezer).Freeze()
+		if err := Apply(frz, Diff(old, vec)); !errors.Is(err, ErrFrozen) {
+			t.Errorf("expected frozen error, got %v", err)
+		}
+		if Compare(frz, old) != 0 {
+			t.Errorf("frozen vector was changed: %v", frz)
+		}
+	})
+	t.Run("write failed", func(t *testing.T) {
+		old, _ := Parse("1,5,70,199", KindVector)
+		vec, _ := Parse("1,6,70-80,130,199", KindVector)
+		src := old.Clone()
+		fv := &failingVector{Interface: src, fail: 5}
+		if err := Apply(fv, Diff(old, vec)); !errors.Is(err, ErrWriteFailed) {
+			t.Errorf("expected write failed error, got %v", err)
+		}
+		if Compare(src, old) != 0 || src.Size() != old.Popcnt() {
+			t.Errorf("changes must be rolled back, got %v", src)
+		}
+	})
+}
+
+// failingVector fails writing of fail-th bit flip.
+type failingVector struct {
+	Interface
+	n, fail int
+}
+
+func (vec *failingVector) Xor(i uint64) bool {
+	vec.n++
+	return vec.n != vec.fail && vec.Interface.Xor(i)
+}
+
+func (vec *failingVector) Set(uint64) bool   { return false }
+func (vec *failingVector) Unset(uint64) bool { return false }
@@ -0,0 +1,110 @@
+package bitvector
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// Pool represents storage of reusable vectors of the same kind.
+//
+// Vectors are split to capacity classes: class k contains vectors capable to store 2^k bits. Get takes the vector from
+// the class of nearest power of two greater or equal to requested size, so memory overhead of pooled vector doesn't
+// exceed twice requested size. Roaring vectors have no capacity, thus they are stored in the single class.
+type Pool struct {
+	// Kind of vectors in the pool.
+	Kind Kind
+	// WriteAttemptsLimit applies to concurrent vectors, see NewConcurrentVector.
+	WriteAttemptsLimit uint64
+
+	p [64]sync.Pool
+}
+
+// Get takes vector from the pool or makes new one. Returned vector is reset and has given size.
+func (p *Pool) Get(size uint64) (Interface, error) {
+	if size == 0 && p.Kind != KindRoaringVector {
+		return nil, ErrZeroSize
+	}
+	if size > math.MaxUint64/2 {
+		return newOfKind(p.Kind, size, p.WriteAttemptsLimit)
+	}
+	// Every vector of the class of 2*size-1 bits is capable to store size bits.
+	cls := p.class(2*size - 1)
+	if x := p.p[cls].Get(); x != nil {
+		vec := x.(Interface)
+		if p.fit(vec, size) {
+			return vec, nil
+		}
+		p.p[cls].Put(vec)
+	}
+	vec, err := newOfKind(p.Kind, uint64(1)<<cls, p.WriteAttemptsLimit)
+	if err != nil {
+		return nil, err
+	}
+	p.fit(vec, size)
+	return vec, nil
+}
+
+// Put returns vector to the pool. Frozen vectors, vectors over caller-owned buffers or files (see FromBytes and
+// OpenFileVector) and vectors of other kinds are ignored.
+func (p *Pool) Put(vec Interface) {
+	var n uint64
+	switch x := vec.(type) {
+	case *vector:
+		if p.Kind != KindVector || x.ro || x.borrowed || cap(x.buf) == 0 {
+			return
+		}
+		n = uint64(cap(x.buf))
+	case *concurrentVector:
+		if p.Kind != KindConcurrentVector || x.frozen() || x.borrowed || cap(x.buf) == 0 {
+			return
+		}
+		n = uint64(cap(x.buf))
+	case *roaringVector:
+		if p.Kind != KindRoaringVector || x.ro {
+			return
+		}
+		p.p[0].Put(x)
+		return
+	default:
+		return
+	}
+	p.p[p.class(p.usable(n))].Put(vec)
+}
+
+// usable returns the greatest size the vector with buffer of n items can be fitted to, see fit.
+func (p *Pool) usable(n uint64) uint64 {
+	if p.Kind == KindConcurrentVector {
+		return n*32 - 1
+	}
+	return n*8 - 1
+}
+
+// class returns index of capacity class of vectors capable to store given number of bits. Vectors with the smallest
+// buffer form the lowest class.
+func (p *Pool) class(size uint64) int {
+	if p.Kind == KindRoaringVector {
+		return 0
+	}
+	return bits.Len64(max(size, p.usable(1))) - 1
+}
+
+// fit checks capacity of the vector, adjusts its size and resets it.
+func (p *Pool) fit(vec Interface, size uint64) bool {
+	switch x := vec.(type) {
+	case *vector:
+		n := size/8 + 1
+		if uint64(cap(x.buf)) < n {
+			return false
+		}
+		x.buf, x.c, x.s, x.o, x.md = x.buf[:n], size, 0, 0, nil
+	case *concurrentVector:
+		n := size/32 + 1
+		if uint64(cap(x.buf)) < n {
+			return false
+		}
+		x.buf, x.c, x.s, x.lim, x.md = x.buf[:n], size, 0, p.WriteAttemptsLimit+1, nil
+	}
+	vec.Reset()
+	return true
+}
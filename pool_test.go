@@ -0,0 +1,94 @@
+package bitvector
+
+import "testing"
+
+func TestPool(t *testing.T) {
+	for _, kind := range []Kind{KindVector, KindConcurrentVector} {
+		t.Run(kind.String(), func(t *testing.T) {
+			p := Pool{Kind: kind}
+			for _, size := range []uint64{1, 10, 100, 64, 65, 1000, 100} {
+				vec, err := p.Get(size)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if vec.Capacity() < size {
+					t.Errorf("size %d: capacity %d is too small", size, vec.Capacity())
+				}
+				if vec.Popcnt() != 0 || vec.Size() != 0 {
+					t.Errorf("size %d: vector isn't reset", size)
+				}
+				for i := uint64(0); i < size; i++ {
+					vec.Set(i)
+				}
+				p.Put(vec)
+			}
+			if _, err := p.Get(0); err != ErrZeroSize {
+				t.Errorf("expected zero size error, got %v", err)
+			}
+		})
+	}
+	t.Run("reuse", func(t *testing.T) {
+		for _, kind := range []Kind{KindVector, KindConcurrentVector} {
+			for _, size := range []uint64{1, 2, 3, 8, 100, 1000} {
+				p := Pool{Kind: kind}
+				var reused int
+				// Pool may drop vectors (e.g. with race detector), so it's enough to reuse at least once.
+				for i := 0; i < 10; i++ {
+					vec, _ := p.Get(size)
+					p.Put(vec)
+					if vec1, _ := p.Get(size); vec1 == vec {
+						reused++
+					}
+				}
+				if reused == 0 {
+					t.Errorf("%s of size %d: vector isn't reused", kind, size)
+				}
+			}
+		}
+	})
+	t.Run("foreign", func(t *testing.T) {
+		p := Pool{Kind: KindVector}
+		cvec, _ := NewConcurrentVector(100, 0)
+		p.Put(cvec)
+		vec, _ := NewVector(100)
+		vec.Set(1)
+		p.Put(vec.(Freezer).Freeze())
+		for i := 0; i < 10; i++ {
+			x, _ := p.Get(100)
+			if _, ok := x.(*vector); !ok || x.Get(1) != 0 {
+				t.Fatal("foreign vector taken from the pool")
+			}
+		}
+	})
+	t.Run("borrowed", func(t *testing.T) {
+		buf := []byte{0x20, 0, 0, 0, 0, 0, 0, 0, 0}
+		buf32 := []uint32{0x20, 0, 0}
+		vec, _ := FromBytes(buf, 64)
+		avec, _ := FromArrow(ArrowBitmap{Buf: buf, Length: 64})
+		cvec, _ := ConcurrentFromUint32(buf32, 64, 0)
+		p, cp := Pool{Kind: KindVector}, Pool{Kind: KindConcurrentVector}
+		p.Put(vec)
+		p.Put(avec)
+		cp.Put(cvec)
+		for i := 0; i < 10; i++ {
+			x, _ := p.Get(64)
+			y, _ := cp.Get(64)
+			if &bytesOf(x)[0] == &buf[0] || &y.(*concurrentVector).buf[0] == &buf32[0] {
+				t.Fatal("borrowed vector taken from the pool")
+			}
+		}
+		if buf[0] != 0x20 || buf32[0] != 0x20 {
+			t.Error("borrowed buffer was reset")
+		}
+	})
+}
+
+func BenchmarkPool(b *testing.B) {
+	b.ReportAllocs()
+	p := Pool{Kind: KindVector}
+	for i := 0; i < b.N; i++ {
+		vec, _ := p.Get(1000)
+		vec.Set(999)
+		p.Put(vec)
+	}
+}
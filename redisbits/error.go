@@ -0,0 +1,5 @@
+package redisbits
+
+import "errors"
+
+var ErrWrongArgs = errors.New("wrong number of arguments")
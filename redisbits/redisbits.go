@@ -0,0 +1,216 @@
+// Package redisbits provides compatibility layer with Redis bitmaps, i.e. string values modified by SETBIT.
+//
+// Redis addresses bits MSB-first: offset 0 is the most significant bit of the first byte. Load wraps Redis string
+// value into the vector with MSB-first order, thus Dump returns exactly the same bytes. Functions of this package
+// expect vectors produced by Load or BitOp. Empty (or missing) Redis string is represented by nil vector.
+package redisbits
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/koykov/bitvector"
+)
+
+// Unit represents unit of range bounds of BitCount and BitPos.
+type Unit uint8
+
+const (
+	// Byte means that range bounds are byte indexes (BYTE option).
+	Byte Unit = iota
+	// Bit means that range bounds are bit offsets (BIT option).
+	Bit
+)
+
+// Unbounded may be used as end argument of BitPos to indicate that end of range isn't specified.
+const Unbounded = math.MaxInt64
+
+// Op represents BITOP operation.
+type Op uint8
+
+const (
+	And Op = iota
+	Or
+	Xor
+	Not
+)
+
+// Load makes vector over Redis string value val without copying. Vector borrows val, see bitvector.FromBytes.
+func Load(val []byte) (bitvector.Interface, error) {
+	if len(val) == 0 {
+		return nil, nil
+	}
+	return bitvector.FromBytesWithOrder(val, uint64(len(val))*8, bitvector.MSBFirst)
+}
+
+// Dump appends Redis string value of the vector to dst and returns the extended slice.
+func Dump(dst []byte, vec bitvector.Interface) []byte {
+	if vec == nil {
+		return dst
+	}
+	return append(dst, bytesOf(vec)...)
+}
+
+// BitCount returns number of set bits in range [start, end] like BITCOUNT command does.
+//
+// Negative indexes count from the end of the string, so BitCount(vec, 0, -1, Byte) counts the whole string.
+func BitCount(vec bitvector.Interface, start, end int64, unit Unit) uint64 {
+	buf := bytesOf(vec)
+	lo, hi, ok := normalize(start, end, len(buf), unit)
+	if !ok {
+		return 0
+	}
+	if unit == Byte {
+		return popcnt(buf[lo : hi+1])
+	}
+	lb, hb := lo/8, hi/8
+	mlo, mhi := uint8(0xff)>>(lo%8), uint8(0xff)<<(7-hi%8)
+	if lb == hb {
+		return uint64(bits.OnesCount8(buf[lb] & mlo & mhi))
+	}
+	return uint64(bits.OnesCount8(buf[lb]&mlo)) + popcnt(buf[lb+1:hb]) + uint64(bits.OnesCount8(buf[hb]&mhi))
+}
+
+// BitPos returns offset of the first bit set (bit == 1) or clear (bit == 0) in range [start, end] like BITPOS
+// command does. Returns -1 if bit wasn't found.
+//
+// Use Unbounded as end to search till the end of the string. In that case search of clear bit in the string filled
+// with ones returns offset of the first bit after the string, as Redis does.
+func BitPos(vec bitvector.Interface, bit uint8, start, end int64, unit Unit) int64 {
+	buf := bytesOf(vec)
+	if len(buf) == 0 {
+		if bit == 0 {
+			return 0
+		}
+		return -1
+	}
+	unbounded := end == Unbounded
+	if unbounded {
+		end = -1
+	}
+	lo, hi, ok := normalize(start, end, len(buf), unit)
+	if !ok {
+		return -1
+	}
+	if unit == Byte {
+		lo, hi = lo*8, hi*8+7
+	}
+	var skip uint8
+	if bit == 0 {
+		skip = 0xff
+	}
+	for i := lo / 8; i <= hi/8; i++ {
+		b := buf[i] ^ skip
+		if i == lo/8 {
+			b &= 0xff >> (lo % 8)
+		}
+		if i == hi/8 {
+			b &= 0xff << (7 - hi%8)
+		}
+		if b != 0 {
+			return int64(i*8 + bits.LeadingZeros8(b))
+		}
+	}
+	if bit == 0 && unbounded {
+		return int64(len(buf)) * 8
+	}
+	return -1
+}
+
+// BitOp performs bitwise operation between srcs like BITOP command does and returns the result vector.
+//
+// The result has the length of the longest source, shorter sources are considered zero-padded. Not requires exactly one
+// source.
+func BitOp(op Op, srcs ...bitvector.Interface) (bitvector.Interface, error) {
+	if len(srcs) == 0 || (op == Not && len(srcs) != 1) {
+		return nil, ErrWrongArgs
+	}
+	var n int
+	for _, src := range srcs {
+		n = max(n, len(bytesOf(src)))
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	dst, err := pad(srcs[0], n)
+	if err != nil {
+		return nil, err
+	}
+	if op == Not {
+		dst.Invert()
+		return dst, nil
+	}
+	for _, src := range srcs[1:] {
+		var x bitvector.Interface
+		if x, err = pad(src, n); err != nil {
+			return nil, err
+		}
+		switch op {
+		case And:
+			err = dst.Filter(x)
+		case Or:
+			err = dst.Merge(x)
+		case Xor:
+			// a ^ b = (a | b) & ^(a & b)
+			nand := dst.Clone()
+			if err = nand.Filter(x); err != nil {
+				return nil, err
+			}
+			nand.Invert()
+			if err = dst.Merge(x); err == nil {
+				err = dst.Filter(nand)
+			}
+		default:
+			return nil, ErrWrongArgs
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+// normalize converts Redis range bounds to absolute inclusive indexes in given unit.
+func normalize(start, end int64, n int, unit Unit) (lo, hi int, ok bool) {
+	total := int64(n)
+	if unit == Bit {
+		total *= 8
+	}
+	if start < 0 {
+		start = max(start+total, 0)
+	}
+	if end < 0 {
+		end = max(end+total, 0)
+	}
+	end = min(end, total-1)
+	if total == 0 || start > end {
+		return
+	}
+	return int(start), int(end), true
+}
+
+// pad makes a copy of src with length n bytes.
+func pad(src bitvector.Interface, n int) (bitvector.Interface, error) {
+	buf := make([]byte, n)
+	copy(buf, bytesOf(src))
+	return bitvector.FromBytesWithOrder(buf, uint64(n)*8, bitvector.MSBFirst)
+}
+
+func popcnt(buf []byte) uint64 {
+	if len(buf) == 0 {
+		return 0
+	}
+	vec, err := bitvector.FromBytes(buf, uint64(len(buf))*8)
+	if err != nil {
+		return 0
+	}
+	return vec.Popcnt()
+}
+
+// bytesOf returns Redis string value of the vector. Vectors without dense representation are empty.
+func bytesOf(vec bitvector.Interface) []byte {
+	if d, ok := vec.(bitvector.Dense); ok {
+		return d.Bytes()
+	}
+	return nil
+}
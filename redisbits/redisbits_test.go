@@ -0,0 +1,99 @@
+package redisbits
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/koykov/bitvector"
+)
+
+func TestCommands(t *testing.T) {
+	f, err := os.Open("testdata/commands.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	keys := make(map[string][]byte)
+	load := func(t *testing.T, key string) bitvector.Interface {
+		vec, err := Load(keys[key])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vec
+	}
+	args := func(args []string) (start, end int64, unit Unit) {
+		start, end, unit = 0, -1, Byte
+		if len(args) > 0 {
+			start, _ = strconv.ParseInt(args[0], 10, 64)
+		}
+		if len(args) > 1 {
+			end, _ = strconv.ParseInt(args[1], 10, 64)
+		}
+		if len(args) > 2 && args[2] == "BIT" {
+			unit = Bit
+		}
+		return
+	}
+
+	scr := bufio.NewScanner(f)
+	for scr.Scan() {
+		line := strings.TrimSpace(scr.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		cmd, want, _ := strings.Cut(line, " => ")
+		t.Run(cmd, func(t *testing.T) {
+			fields := strings.Fields(cmd)
+			switch fields[0] {
+			case "SET":
+				val, err := hex.DecodeString(fields[2])
+				if err != nil {
+					t.Fatal(err)
+				}
+				keys[fields[1]] = val
+				vec := load(t, fields[1])
+				if r := Dump(nil, vec); !bytes.Equal(r, val) {
+					t.Errorf("dump mismatch: %x", r)
+				}
+			case "BITCOUNT":
+				start, end, unit := args(fields[2:])
+				if r := strconv.FormatUint(BitCount(load(t, fields[1]), start, end, unit), 10); r != want {
+					t.Errorf("got %s, want %s", r, want)
+				}
+			case "BITPOS":
+				bit, _ := strconv.Atoi(fields[2])
+				start, end, unit := args(fields[3:])
+				if len(fields) < 5 {
+					end = Unbounded
+				}
+				if r := strconv.FormatInt(BitPos(load(t, fields[1]), uint8(bit), start, end, unit), 10); r != want {
+					t.Errorf("got %s, want %s", r, want)
+				}
+			case "BITOP":
+				op := map[string]Op{"AND": And, "OR": Or, "XOR": Xor, "NOT": Not}[fields[1]]
+				var srcs []bitvector.Interface
+				for _, key := range fields[3:] {
+					srcs = append(srcs, load(t, key))
+				}
+				vec, err := BitOp(op, srcs...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r := hex.EncodeToString(Dump(nil, vec)); r != want {
+					t.Errorf("got %s, want %s", r, want)
+				}
+			default:
+				t.Fatalf("unknown command %s", fields[0])
+			}
+		})
+	}
+	if err = scr.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,19 +3,21 @@ package bitvector
 import (
 	"encoding/binary"
 	"io"
+	"maps"
 	"math"
-	"sort"
-	"unsafe"
 )
 
 const (
 	roaringVectorDumpSignature = 0x9cf814f5923ac3bf
-	roaringVectorDumpVersion   = 1.0
+	roaringVectorDumpVersion1  = 1.0
+	roaringVectorDumpVersion2  = 2.0
 )
 
 type roaringVector struct {
 	rvector
 	cpy rvector
+	ro  bool
+	md  Metadata
 }
 
 type rvector struct {
@@ -25,15 +27,31 @@ type rvector struct {
 }
 
 func (vec *roaringVector) Set(x uint64) bool {
+	if vec.ro {
+		return false
+	}
 	hib, lob := vec.hibits(x), vec.lobits(x)
 	return vec.setHL(hib, lob)
 }
 
+func (vec *roaringVector) SetRange(lo, hi uint64) bool {
+	if vec.ro || lo > hi {
+		return false
+	}
+	for x := lo; x < hi; x++ {
+		vec.Set(x)
+	}
+	return true
+}
+
 func (vec *roaringVector) Xor(uint64) bool {
 	return false // not implemented
 }
 
 func (vec *roaringVector) Unset(x uint64) bool {
+	if vec.ro {
+		return false
+	}
 	hib, lob := vec.hibits(x), vec.lobits(x)
 	i := vec.indexhb(hib)
 	if i < 0 {
@@ -46,12 +64,12 @@ func (vec *roaringVector) Unset(x uint64) bool {
 	}
 	if bm.size() == 0 {
 		copy(vec.keys[i:], vec.keys[i+1:])
-		vec.keys = vec.keys[len(vec.keys)-1:]
+		vec.keys = vec.keys[:len(vec.keys)-1]
 		copy(vec.buf[i:], vec.buf[i+1:])
-		vec.buf = vec.buf[len(vec.buf)-1:]
+		vec.buf = vec.buf[:len(vec.buf)-1]
 		vec.cow.delete(i)
 	}
-	return false
+	return true
 }
 
 func (vec *roaringVector) Get(x uint64) uint8 {
@@ -75,7 +93,7 @@ func (vec *roaringVector) Popcnt() (c uint64) {
 }
 
 func (vec *roaringVector) Difference(p Interface) (uint64, error) {
-	inst, ok := any(p).(*roaringVector)
+	inst, ok := unwrapJournal(p).(*roaringVector)
 	if !ok {
 		return 0, ErrWrongType
 	}
@@ -119,7 +137,10 @@ func (vec *roaringVector) Difference(p Interface) (uint64, error) {
 }
 
 func (vec *roaringVector) Merge(p Interface) error {
-	inst, ok := any(p).(*roaringVector)
+	if vec.ro {
+		return ErrFrozen
+	}
+	inst, ok := unwrapJournal(p).(*roaringVector)
 	if !ok {
 		return ErrWrongType
 	}
@@ -139,7 +160,10 @@ func (vec *roaringVector) Merge(p Interface) error {
 }
 
 func (vec *roaringVector) Filter(p Interface) error {
-	inst, ok := any(p).(*roaringVector)
+	if vec.ro {
+		return ErrFrozen
+	}
+	inst, ok := unwrapJournal(p).(*roaringVector)
 	if !ok {
 		return ErrWrongType
 	}
@@ -182,6 +206,7 @@ func (vec *roaringVector) Clone() Interface {
 			buf:  make([]*bitmap, len(vec.buf)),
 			cow:  vec.cow.clone(),
 		},
+		md: maps.Clone(vec.md),
 	}
 	for i := 0; i < len(vec.buf); i++ {
 		cpy.buf[i] = vec.buf[i].clone()
@@ -189,104 +214,116 @@ func (vec *roaringVector) Clone() Interface {
 	return cpy
 }
 
+// Freeze makes the vector read-only and returns it.
+//
+// All further modifications of the vector will fail. Use Clone to get mutable copy of frozen vector.
+func (vec *roaringVector) Freeze() Interface {
+	vec.ro = true
+	return vec
+}
+
 func (vec *roaringVector) ReadFrom(r io.Reader) (n int64, err error) {
+	if vec.ro {
+		return 0, ErrFrozen
+	}
 	var (
-		buf [24]byte
-		m   int
+		buf [8]byte
+		cr  = crcReader{r: r}
 	)
-	m, err = r.Read(buf[:])
-	n += int64(m)
+	defer func() { n = cr.n }()
+	h, err := readHeader(&cr, roaringVectorDumpSignature)
 	if err != nil {
-		return n, err
+		return
 	}
-
-	sign, ver, ln := binary.LittleEndian.Uint64(buf[0:8]), binary.LittleEndian.Uint64(buf[8:16]),
-		binary.LittleEndian.Uint64(buf[16:24])
-
-	if sign != roaringVectorDumpSignature {
-		return n, ErrInvalidSignature
+	ln, flags := h.fields[0], h.flags
+	if flags&^(dumpFlagChecksum|dumpFlagCompressed|dumpFlagMetadata) != 0 {
+		return n, ErrUnknownFlags
 	}
-	if ver != math.Float64bits(roaringVectorDumpVersion) {
-		return n, ErrVersionMismatch
+	// Keys are upper 32 bits of positions.
+	if ln > math.MaxUint32+1 {
+		return n, ErrCorrupted
 	}
 
-	vec.keys = make([]uint32, ln)
-	h1 := *(*hslice)(unsafe.Pointer(&vec.keys))
-	h1.l *= 4
-	h1.c *= 4
-	buf1 := *(*[]byte)(unsafe.Pointer(&h1))
-	m, err = r.Read(buf1)
-	n += int64(m)
-	if err != nil {
+	// Header doesn't promise size of bitmaps, so decompressed payload isn't bounded.
+	pr, done := payloadReader(&cr, flags, math.MaxUint64)
+	var rv rvector
+	if rv.keys, err = readSlice[uint32](pr, nil, ln); err != nil {
 		return
 	}
 
-	m, err = r.Read(buf[:8])
-	ln = binary.LittleEndian.Uint64(buf[0:8])
-	vec.buf = make([]*bitmap, ln)
-	var n1 int64
+	if _, err = readFull(pr, buf[:8]); err != nil {
+		return
+	}
+	if binary.LittleEndian.Uint64(buf[0:8]) != ln {
+		return n, ErrCorrupted
+	}
+	rv.buf = make([]*bitmap, 0, min(ln, dumpChunkSz/8))
 	for i := uint64(0); i < ln; i++ {
-		vec.buf[i] = &bitmap{}
-		n1, err = vec.buf[i].readFrom(r)
-		n += n1
-		if err != nil {
+		bm := &bitmap{}
+		if _, err = bm.readFrom(pr); err != nil {
 			return
 		}
+		rv.buf = append(rv.buf, bm)
 	}
 
-	n1, err = vec.cow.readFrom(r)
-	n += n1
-
+	if _, err = rv.cow.readFrom(pr); err != nil {
+		return
+	}
+	if err = done(); err != nil {
+		return
+	}
+	if flags&dumpFlagChecksum != 0 {
+		if err = cr.readChecksum(); err != nil {
+			return
+		}
+	}
+	vec.rvector, vec.md = rv, h.md
 	return
 }
 
-func (vec *roaringVector) WriteTo(w io.Writer) (n int64, err error) {
+func (vec *roaringVector) WriteTo(w io.Writer) (int64, error) {
+	return vec.dump(w, dumpFlagChecksum)
+}
+
+// dump writes the vector with given dump flags.
+func (vec *roaringVector) dump(w io.Writer, flags uint64) (int64, error) {
 	var (
-		buf [24]byte
-		m   int
+		buf [8]byte
+		cw  = crcWriter{w: w}
 	)
-	binary.LittleEndian.PutUint64(buf[0:8], roaringVectorDumpSignature)
-	binary.LittleEndian.PutUint64(buf[8:16], math.Float64bits(roaringVectorDumpVersion))
-	binary.LittleEndian.PutUint64(buf[16:24], uint64(len(vec.keys)))
-	m, err = w.Write(buf[:])
-	n += int64(m)
-	if err != nil {
-		return int64(m), err
+	if err := writeHeader(&cw, roaringVectorDumpSignature, flags, vec.md, uint64(len(vec.keys))); err != nil {
+		return cw.n, err
 	}
 
-	h1 := *(*hslice)(unsafe.Pointer(&vec.keys))
-	h1.l *= 4
-	h1.c *= 4
-	buf1 := *(*[]byte)(unsafe.Pointer(&h1))
-	if m, err = w.Write(buf1); err != nil {
-		return
+	pw, done := payloadWriter(&cw, flags)
+	if _, err := writeSlice(pw, vec.keys); err != nil {
+		return cw.n, err
 	}
-	n += int64(m)
 
 	binary.LittleEndian.PutUint64(buf[0:8], uint64(len(vec.buf)))
-	m, err = w.Write(buf[:8])
-	n += int64(m)
-	if err != nil {
-		return int64(m), err
+	if _, err := pw.Write(buf[:8]); err != nil {
+		return cw.n, err
 	}
 	for i := 0; i < len(vec.buf); i++ {
-		n1, err := vec.buf[i].writeTo(w)
-		if err != nil {
-			return n, err
+		if _, err := vec.buf[i].writeTo(pw); err != nil {
+			return cw.n, err
 		}
-		n += n1
 	}
-
-	n1, err := vec.cow.writeTo(w)
-	if err != nil {
-		return
+	if _, err := vec.cow.writeTo(pw); err != nil {
+		return cw.n, err
+	}
+	if err := done(); err != nil {
+		return cw.n, err
 	}
-	n += n1
 
-	return
+	err := cw.writeChecksum()
+	return cw.n, err
 }
 
 func (vec *roaringVector) Reset() {
+	if vec.ro {
+		return
+	}
 	vec.rvector.Reset()
 }
 
@@ -344,9 +381,7 @@ func (vec *rvector) indexhb(hb uint32) int {
 	if hb == vec.keys[n-1] {
 		return n - 1
 	}
-	return sort.Search(n, func(i int) bool {
-		return vec.keys[i] == hb
-	})
+	return search(vec.keys, hb)
 }
 
 func (vec *rvector) addhb(i int, hb uint32, bm *bitmap) {
@@ -0,0 +1,53 @@
+package bitvector
+
+import "testing"
+
+func TestRoaringVector(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		vec := &roaringVector{}
+		pos := []uint64{9, 3, 1<<32 + 5, 7, 1 << 32, 3}
+		for _, x := range pos {
+			vec.Set(x)
+		}
+		for _, x := range pos {
+			if vec.Get(x) != 1 {
+				t.Errorf("bit %d isn't set", x)
+			}
+		}
+		for _, x := range []uint64{0, 4, 8, 1<<32 + 4} {
+			if vec.Get(x) != 0 {
+				t.Errorf("bit %d must be clear", x)
+			}
+		}
+		if vec.Popcnt() != 5 {
+			t.Errorf("unexpected popcnt %d", vec.Popcnt())
+		}
+	})
+	t.Run("unset", func(t *testing.T) {
+		vec := &roaringVector{}
+		vec.Set(3)
+		vec.Set(1<<32 + 1)
+		vec.Set(2<<32 + 1)
+		if !vec.Unset(1<<32 + 1) {
+			t.Error("unset of existing bit must succeed")
+		}
+		if vec.Unset(1<<32+1) || vec.Unset(5) {
+			t.Error("unset of missing bit must fail")
+		}
+		// Removing the last bit of a container must keep other containers.
+		if vec.Get(3) != 1 || vec.Get(2<<32+1) != 1 || vec.Get(1<<32+1) != 0 {
+			t.Error("unexpected state after unset")
+		}
+	})
+	t.Run("large container", func(t *testing.T) {
+		vec := &roaringVector{}
+		const n = 5000
+		for i := uint64(0); i < n; i++ {
+			vec.Set(i * 2)
+		}
+		vec.Set(1)
+		if vec.Popcnt() != n+1 || vec.Get(1) != 1 || vec.Get((n-1)*2) != 1 {
+			t.Errorf("unexpected popcnt %d", vec.Popcnt())
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package bitvector
+
+import (
+	"bytes"
+	"database/sql/driver"
+)
+
+var (
+	_ driver.Valuer = (*vector)(nil)
+	_ driver.Valuer = PgBitVarying{}
+)
+
+// Value implements driver.Valuer. Vector is stored as binary dump.
+func (vec *vector) Value() (driver.Value, error) {
+	return dumpValue(vec)
+}
+
+// Scan implements sql.Scanner. Source must be a binary dump.
+func (vec *vector) Scan(src any) error {
+	return scanDump(vec, src)
+}
+
+// Value implements driver.Valuer. Vector is stored as binary dump.
+func (vec *concurrentVector) Value() (driver.Value, error) {
+	return dumpValue(vec)
+}
+
+// Scan implements sql.Scanner. Source must be a binary dump.
+func (vec *concurrentVector) Scan(src any) error {
+	return scanDump(vec, src)
+}
+
+// Value implements driver.Valuer. Vector is stored as binary dump of the source concurrent vector.
+func (vec *frozenVector) Value() (driver.Value, error) {
+	return dumpValue(vec)
+}
+
+// Value implements driver.Valuer. Vector is stored as binary dump.
+func (vec *roaringVector) Value() (driver.Value, error) {
+	return dumpValue(vec)
+}
+
+// Scan implements sql.Scanner. Source must be a binary dump.
+func (vec *roaringVector) Scan(src any) error {
+	return scanDump(vec, src)
+}
+
+func dumpValue(v Interface) (driver.Value, error) {
+	var buf bytes.Buffer
+	if _, err := v.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func scanDump(v Interface, src any) error {
+	var p []byte
+	switch x := src.(type) {
+	case nil:
+		v.Reset()
+		return nil
+	case []byte:
+		p = x
+	case string:
+		p = []byte(x)
+	default:
+		return ErrWrongType
+	}
+	_, err := v.ReadFrom(bytes.NewReader(p))
+	return err
+}
+
+// PgBitVarying wraps the vector to store it in PostgreSQL bit varying text form, e.g. "0101". The first character
+// represents bit at position 0.
+type PgBitVarying struct {
+	Vec Interface
+	// Kind of the vector made by Scan if Vec is nil.
+	Kind Kind
+
+	// Scanned bit string is empty, see Scan.
+	empty bool
+}
+
+// Value implements driver.Valuer.
+func (v PgBitVarying) Value() (driver.Value, error) {
+	if v.Vec == nil {
+		if v.empty {
+			return "", nil
+		}
+		return nil, nil
+	}
+	size := sizeOf(v.Vec)
+	buf := bytes.Repeat([]byte{'0'}, int(size))
+	forEach(v.Vec, func(p uint64) bool {
+		if p >= size {
+			return false
+		}
+		buf[p] = '1'
+		return true
+	})
+	return string(buf), nil
+}
+
+// Scan implements sql.Scanner. Scan replaces Vec with the new vector of the same kind.
+//
+// Empty bit string makes empty roaring vector. Other kinds can't be empty, so Vec becomes nil, but unlike NULL it's
+// written back by Value as empty bit string.
+func (v *PgBitVarying) Scan(src any) error {
+	var s string
+	switch x := src.(type) {
+	case nil:
+		v.Vec, v.empty = nil, false
+		return nil
+	case []byte:
+		s = string(x)
+	case string:
+		s = x
+	default:
+		return ErrWrongType
+	}
+	kind := v.Kind
+	if v.Vec != nil {
+		kind = kindOf(v.Vec)
+	}
+	if s == "" && kind != KindRoaringVector {
+		v.Vec, v.empty = nil, true
+		return nil
+	}
+	vec, err := Parse("0b"+s, kind)
+	if err != nil {
+		if perr, ok := err.(*ParseError); ok {
+			perr.Offset -= 2
+		}
+		return err
+	}
+	v.Vec, v.empty = vec, false
+	return nil
+}
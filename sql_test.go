@@ -0,0 +1,171 @@
+package bitvector
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeDriver is an in-process key-value storage driver. It supports two queries:
+//   - "put" with arguments key and value;
+//   - "get" with argument key returning single row with the value.
+type fakeDriver struct {
+	mux sync.Mutex
+	kv  map[string]driver.Value
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+type fakeRows struct {
+	vals []driver.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.query != "put" || len(args) != 2 {
+		return nil, errors.New("unsupported query")
+	}
+	s.c.d.mux.Lock()
+	defer s.c.d.mux.Unlock()
+	s.c.d.kv[args[0].(string)] = args[1]
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.query != "get" || len(args) != 1 {
+		return nil, errors.New("unsupported query")
+	}
+	s.c.d.mux.Lock()
+	defer s.c.d.mux.Unlock()
+	return &fakeRows{vals: []driver.Value{s.c.d.kv[args[0].(string)]}}, nil
+}
+
+func (r *fakeRows) Columns() []string { return []string{"value"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	dest[0], r.vals = r.vals[0], r.vals[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("bitvector-fake", &fakeDriver{kv: make(map[string]driver.Value)})
+}
+
+func TestSQL(t *testing.T) {
+	db, err := sql.Open("bitvector-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	const pos = "3,5,7-9,100"
+	t.Run("dump", func(t *testing.T) {
+		for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+			vec, _ := Parse(pos, kind)
+			if _, err = db.Exec("put", kind.String(), vec); err != nil {
+				t.Fatal(err)
+			}
+			vec1, _ := newOfKind(kind, 1, 0)
+			if err = db.QueryRow("get", kind.String()).Scan(vec1); err != nil {
+				t.Fatal(err)
+			}
+			if Compare(vec, vec1) != 0 {
+				t.Errorf("%s: got %v, want %v", kind, vec1, vec)
+			}
+		}
+	})
+	t.Run("frozen", func(t *testing.T) {
+		vec, _ := Parse(pos, KindConcurrentVector)
+		if _, err = db.Exec("put", "frozen", vec.(Freezer).Freeze()); err != nil {
+			t.Fatal(err)
+		}
+		vec1, _ := NewConcurrentVector(1, 0)
+		if err = db.QueryRow("get", "frozen").Scan(vec1); err != nil {
+			t.Fatal(err)
+		}
+		if Compare(vec, vec1) != 0 {
+			t.Errorf("got %v, want %v", vec1, vec)
+		}
+	})
+	t.Run("bit varying", func(t *testing.T) {
+		vec, _ := Parse("1,3", KindVector)
+		if _, err = db.Exec("put", "bits", PgBitVarying{Vec: vec}); err != nil {
+			t.Fatal(err)
+		}
+		var s string
+		if err = db.QueryRow("get", "bits").Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		if s != "0101" {
+			t.Errorf("unexpected text form %q", s)
+		}
+		bv := PgBitVarying{Kind: KindConcurrentVector}
+		if err = db.QueryRow("get", "bits").Scan(&bv); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := bv.Vec.(*concurrentVector); !ok || Compare(vec, bv.Vec) != 0 {
+			t.Errorf("got %v, want %v", bv.Vec, vec)
+		}
+		if err = bv.Scan("01x"); err == nil || err.(*ParseError).Offset != 2 {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+	t.Run("empty bit varying", func(t *testing.T) {
+		for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+			bv := PgBitVarying{Kind: kind}
+			if err = bv.Scan(""); err != nil {
+				t.Fatalf("%s: %v", kind, err)
+			}
+			if bv.Vec != nil && bv.Vec.Popcnt() != 0 {
+				t.Errorf("%s: vector must be empty", kind)
+			}
+			if _, err = db.Exec("put", "empty", bv); err != nil {
+				t.Fatal(err)
+			}
+			var s sql.NullString
+			if err = db.QueryRow("get", "empty").Scan(&s); err != nil {
+				t.Fatal(err)
+			}
+			if !s.Valid || s.String != "" {
+				t.Errorf("%s: expected empty bit string, got %v", kind, s)
+			}
+			if err = bv.Scan(nil); err != nil {
+				t.Fatal(err)
+			}
+			if x, _ := bv.Value(); x != nil {
+				t.Errorf("%s: expected NULL, got %q", kind, x)
+			}
+		}
+	})
+	t.Run("null", func(t *testing.T) {
+		vec, _ := Parse(pos, KindVector)
+		if err = db.QueryRow("get", "missing").Scan(vec); err != nil {
+			t.Fatal(err)
+		}
+		if vec.Popcnt() != 0 {
+			t.Error("vector must be reset")
+		}
+	})
+}
@@ -1,21 +1,25 @@
 package bitvector
 
 import (
-	"encoding/binary"
 	"io"
-	"math"
+	"maps"
 	"math/bits"
 	"unsafe"
 
 	"github.com/koykov/simd/bitwise"
 	"github.com/koykov/simd/hamming"
 	"github.com/koykov/simd/memclr"
+	"github.com/koykov/simd/memset"
 	"github.com/koykov/simd/popcnt"
 )
 
 const (
 	vectorDumpSignature = 0x65a5cc221b100738
-	vectorDumpVersion   = 1.0
+	vectorDumpVersion1  = 1.0
+	vectorDumpVersion2  = 2.0
+
+	// Dump header flags.
+	vectorFlagMSB = 1 << 0
 )
 
 // vector represents simple bit array implementation without race protection. It means you may do concurrent read, but
@@ -23,6 +27,14 @@ const (
 type vector struct {
 	buf  []uint8
 	c, s uint64
+	ro   bool
+	// Buffer is owned by the caller, see FromBytes.
+	borrowed bool
+	// Buffer is mapped file, which can't be replaced, see OpenFileVector.
+	mapped bool
+	// Bit offset mask, 0 for LSB-first and 7 for MSB-first order.
+	o  uint8
+	md Metadata
 }
 
 // NewVector make new bit array with given size.
@@ -36,31 +48,95 @@ func NewVector(size uint64) (Interface, error) {
 	}, nil
 }
 
+// NewVectorWithOrder make new bit array with given size and order of bits within bytes.
+func NewVectorWithOrder(size uint64, order BitOrder) (Interface, error) {
+	vec, err := NewVector(size)
+	if err != nil {
+		return nil, err
+	}
+	vec.(*vector).o = order.mask()
+	return vec, nil
+}
+
+// FromBytes makes new bit array over given buffer without copying.
+//
+// The vector borrows buf: all modifications of the vector are visible in buf and vice versa. The caller must not
+// modify or release buf while the vector is in use. Buffer must contain at least size bits.
+func FromBytes(buf []byte, size uint64) (Interface, error) {
+	return FromBytesWithOrder(buf, size, LSBFirst)
+}
+
+// FromBytesWithOrder makes new bit array over given buffer without copying. Bits in buf must be in given order.
+//
+// Same ownership rules as in FromBytes are applied.
+func FromBytesWithOrder(buf []byte, size uint64, order BitOrder) (Interface, error) {
+	if size == 0 {
+		return nil, ErrZeroSize
+	}
+	if uint64(len(buf))*8 < size {
+		return nil, ErrShortBuffer
+	}
+	vec := &vector{
+		buf:      buf,
+		c:        size,
+		borrowed: true,
+		o:        order.mask(),
+	}
+	vec.s = vec.Popcnt()
+	return vec, nil
+}
+
 // Set writes new bit at given position.
 func (vec *vector) Set(i uint64) bool {
-	if len(vec.buf) <= int(i/8) {
+	if vec.ro || len(vec.buf) <= int(i/8) {
 		return false
 	}
-	vec.buf[i/8] |= 1 << uint8(i%8)
+	vec.buf[i/8] |= 1 << (uint8(i%8) ^ vec.o)
 	vec.s++
 	return true
 }
 
+// SetRange writes bits in range [lo, hi).
+func (vec *vector) SetRange(lo, hi uint64) bool {
+	if vec.ro || lo > hi || uint64(len(vec.buf))*8 < hi {
+		return false
+	}
+	if lo == hi {
+		return true
+	}
+	vec.s += hi - lo
+	lb, hb := lo/8, hi/8
+	mlo, mhi := uint8(0xff)<<(lo%8), ^(uint8(0xff) << (hi % 8))
+	if vec.o != 0 {
+		mlo, mhi = rev8[mlo], rev8[mhi]
+	}
+	if lb == hb {
+		vec.buf[lb] |= mlo & mhi
+		return true
+	}
+	vec.buf[lb] |= mlo
+	memset.Memset(vec.buf[lb+1:hb], 0xff)
+	if mhi != 0 {
+		vec.buf[hb] |= mhi
+	}
+	return true
+}
+
 // Xor applies xor at given position.
 func (vec *vector) Xor(i uint64) bool {
-	if len(vec.buf) <= int(i/8) {
+	if vec.ro || len(vec.buf) <= int(i/8) {
 		return false
 	}
-	vec.buf[i/8] ^= 1 << uint8(i%8)
+	vec.buf[i/8] ^= 1 << (uint8(i%8) ^ vec.o)
 	return true
 }
 
 // Unset clears bit at given position.
 func (vec *vector) Unset(i uint64) bool {
-	if len(vec.buf) <= int(i/8) {
+	if vec.ro || len(vec.buf) <= int(i/8) {
 		return false
 	}
-	vec.buf[i/8] &^= 1 << uint8(i%8)
+	vec.buf[i/8] &^= 1 << (uint8(i%8) ^ vec.o)
 	vec.s--
 	return true
 }
@@ -70,7 +146,7 @@ func (vec *vector) Get(i uint64) uint8 {
 	if len(vec.buf) <= int(i/8) {
 		return 0
 	}
-	return (vec.buf[i/8] & (1 << (i % 8))) >> (i % 8)
+	return (vec.buf[i/8] >> (uint8(i%8) ^ vec.o)) & 1
 }
 
 // Size returns number of items added to the vector.
@@ -113,7 +189,7 @@ func (vec *vector) Popcnt() (r uint64) {
 
 func (vec *vector) Difference(other Interface) (r uint64, err error) {
 	var ovec *vector
-	switch x := any(other).(type) {
+	switch x := unwrapJournal(other).(type) {
 	case *vector:
 		ovec = x
 	default:
@@ -124,6 +200,10 @@ func (vec *vector) Difference(other Interface) (r uint64, err error) {
 		err = ErrNotEqualSize
 		return
 	}
+	if vec.o != ovec.o {
+		err = ErrBitOrderMismatch
+		return
+	}
 	buf := vec.buf
 	obuf := ovec.buf
 	diff := hamming.Distance(buf, obuf)
@@ -140,13 +220,19 @@ func (vec *vector) Filter(other Interface) error {
 }
 
 func (vec *vector) bitwise(other Interface, fn func(a, b []byte)) error {
+	if vec.ro {
+		return ErrFrozen
+	}
 	var ovec *vector
-	switch x := any(other).(type) {
+	switch x := unwrapJournal(other).(type) {
 	case *vector:
 		ovec = x
 	default:
 		return ErrWrongType
 	}
+	if vec.o != ovec.o {
+		return ErrBitOrderMismatch
+	}
 	buf := vec.buf
 	obuf := ovec.buf
 	fn(buf, obuf)
@@ -154,6 +240,9 @@ func (vec *vector) bitwise(other Interface, fn func(a, b []byte)) error {
 }
 
 func (vec *vector) Invert() {
+	if vec.ro {
+		return
+	}
 	bitwise.Not(vec.buf)
 }
 
@@ -162,6 +251,8 @@ func (vec *vector) Clone() Interface {
 		buf: make([]uint8, len(vec.buf)),
 		c:   vec.c,
 		s:   vec.s,
+		o:   vec.o,
+		md:  maps.Clone(vec.md),
 	}
 	copy(clone.buf, vec.buf)
 	return clone
@@ -169,62 +260,104 @@ func (vec *vector) Clone() Interface {
 
 // Reset resets the whole bit array.
 func (vec *vector) Reset() {
-	if len(vec.buf) == 0 {
+	if vec.ro || len(vec.buf) == 0 {
 		return
 	}
 	memclr.Clear(vec.buf)
 }
 
+// Freeze makes the vector read-only and returns it.
+//
+// All further modifications of the vector will fail. Use Clone to get mutable copy of frozen vector.
+func (vec *vector) Freeze() Interface {
+	vec.ro = true
+	return vec
+}
+
+// Bytes returns underlying buffer of the vector.
+func (vec *vector) Bytes() []byte {
+	return vec.buf
+}
+
 func (vec *vector) ReadFrom(r io.Reader) (n int64, err error) {
-	var (
-		buf [32]byte
-		m   int
-	)
-	m, err = r.Read(buf[:])
-	n += int64(m)
+	if vec.ro {
+		return 0, ErrFrozen
+	}
+	if vec.mapped {
+		return 0, ErrFileBacked
+	}
+	cr := crcReader{r: r}
+	defer func() { n = cr.n }()
+	h, err := readHeader(&cr, vectorDumpSignature)
 	if err != nil {
-		return n, err
+		return
+	}
+	c, s, flags := h.fields[0], h.fields[1], h.flags
+	if flags&^(vectorFlagMSB|dumpFlagChecksum|dumpFlagCompressed|dumpFlagBlocks|dumpFlagMetadata) != 0 {
+		return n, ErrUnknownFlags
 	}
 
-	sign, ver, c, s := binary.LittleEndian.Uint64(buf[0:8]), binary.LittleEndian.Uint64(buf[8:16]),
-		binary.LittleEndian.Uint64(buf[16:24]), binary.LittleEndian.Uint64(buf[24:32])
-
-	if sign != vectorDumpSignature {
-		return n, ErrInvalidSignature
+	// Payload is read in place if the buffer is large enough, so the vector is reset if the dump turns out broken.
+	inplace := uint64(cap(vec.buf)) >= c/8+1
+	pr, done := payloadReader(&cr, flags, c/8+1)
+	buf, err := readSlice(pr, vec.buf, c/8+1)
+	if err == nil {
+		err = done()
 	}
-	if ver != math.Float64bits(vectorDumpVersion) {
-		return n, ErrVersionMismatch
+	if err == nil && flags&dumpFlagChecksum != 0 {
+		err = cr.readChecksum()
 	}
-	vec.c, vec.s = c, s
-
-	if uint64(len(vec.buf)) < c/8+1 {
-		vec.buf = make([]uint8, c/8+1)
+	if err != nil {
+		if inplace {
+			clear(vec.buf[:cap(vec.buf)])
+			vec.s = 0
+		}
+		return
 	}
-
-	m, err = r.Read(vec.buf)
-	n += int64(m)
-	if err == io.EOF {
-		err = nil
+	vec.buf, vec.c, vec.s, vec.md = buf, c, s, h.md
+	vec.o = 0
+	if flags&vectorFlagMSB != 0 {
+		vec.o = MSBFirst.mask()
 	}
 	return
 }
 
-func (vec *vector) WriteTo(w io.Writer) (n int64, err error) {
+func (vec *vector) WriteTo(w io.Writer) (int64, error) {
+	return vec.dump(w, dumpFlagChecksum)
+}
+
+// dump writes the vector with given dump flags.
+func (vec *vector) dump(w io.Writer, flags uint64) (int64, error) {
 	var (
-		buf [32]byte
-		m   int
+		buf [64]byte
+		cw  = crcWriter{w: w}
 	)
-	binary.LittleEndian.PutUint64(buf[0:8], vectorDumpSignature)
-	binary.LittleEndian.PutUint64(buf[8:16], math.Float64bits(vectorDumpVersion))
-	binary.LittleEndian.PutUint64(buf[16:24], vec.c)
-	binary.LittleEndian.PutUint64(buf[24:32], vec.s)
-	m, err = w.Write(buf[:])
-	n += int64(m)
-	if err != nil {
-		return int64(m), err
+	if vec.o != 0 {
+		flags |= vectorFlagMSB
+	}
+	if err := writeHeader(&cw, vectorDumpSignature, flags, vec.md, vec.c, vec.s); err != nil {
+		return cw.n, err
 	}
 
-	m, err = w.Write(vec.buf)
-	n += int64(m)
-	return
+	// Payload is always c/8+1 bytes, borrowed buffer may be shorter or longer.
+	pw, done := payloadWriter(&cw, flags)
+	p, need := vec.buf, int(vec.c/8+1)
+	if len(p) > need {
+		p = p[:need]
+	}
+	if _, err := pw.Write(p); err != nil {
+		return cw.n, err
+	}
+	clear(buf[:])
+	for pad := need - len(p); pad > 0; pad -= len(buf) {
+		if _, err := pw.Write(buf[:min(pad, len(buf))]); err != nil {
+			return cw.n, err
+		}
+	}
+	if err := done(); err != nil {
+		return cw.n, err
+	}
+
+	err := cw.writeChecksum()
+	return cw.n, err
 }
@@ -1,7 +1,9 @@
 package bitvector
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"math"
 	"os"
 	"strconv"
@@ -110,6 +112,55 @@ func TestVector(t *testing.T) {
 			t.FailNow()
 		}
 	})
+	t.Run("set range", func(t *testing.T) {
+		stages := []struct{ lo, hi uint64 }{{0, 0}, {3, 6}, {0, 8}, {5, 30}, {8, 16}, {1, 100}, {0, 104}}
+		for _, st := range stages {
+			vec := prepare(100)
+			vec.Reset()
+			if !vec.SetRange(st.lo, st.hi) {
+				t.Errorf("range [%d, %d) wasn't set", st.lo, st.hi)
+			}
+			for i := uint64(0); i < vec.Capacity(); i++ {
+				if want := i >= st.lo && i < st.hi; (vec.Get(i) == 1) != want {
+					t.Errorf("range [%d, %d): bit %d mismatch", st.lo, st.hi, i)
+				}
+			}
+		}
+		if vec := prepare(10); vec.SetRange(3, 17) || vec.SetRange(5, 4) {
+			t.Error("out of range write")
+		}
+	})
+	t.Run("set range fallback", func(t *testing.T) {
+		// Wrapper hides SetRange, so bits are written one by one.
+		vec := struct{ Interface }{prepare(100)}
+		vec.Reset()
+		if !setRange(vec, 5, 70) || vec.Popcnt() != 65 || vec.Get(4) != 0 || vec.Get(69) != 1 {
+			t.Error("range wasn't set")
+		}
+		if setRange(vec, 90, 110) || setRange(vec, 5, 4) {
+			t.Error("out of range write")
+		}
+	})
+	t.Run("from bytes", func(t *testing.T) {
+		buf := []byte{168, 2}
+		vec, err := FromBytes(buf, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chk := map[int]uint8{3: 1, 5: 1, 7: 1, 9: 1}
+		for i := 0; i < 16; i++ {
+			if chk[i] != vec.Get(uint64(i)) {
+				t.Fail()
+			}
+		}
+		vec.Set(15)
+		if buf[1] != 130 || &bytesOf(vec)[0] != &buf[0] {
+			t.Error("buffer isn't shared")
+		}
+		if _, err = FromBytes(buf, 17); err != ErrShortBuffer {
+			t.Errorf("expected short buffer error, got %v", err)
+		}
+	})
 	t.Run("writer", func(t *testing.T) {
 		vec := prepare(10)
 		f, err := os.OpenFile("testdata/vector.bin", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
@@ -120,7 +171,7 @@ func TestVector(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		if n != 34 {
+		if n != 46 {
 			t.Fail()
 		}
 	})
@@ -140,6 +191,24 @@ func TestVector(t *testing.T) {
 			}
 		}
 	})
+	t.Run("reader v1", func(t *testing.T) {
+		var buf []byte
+		buf = binary.LittleEndian.AppendUint64(buf, vectorDumpSignature)
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(vectorDumpVersion1))
+		buf = binary.LittleEndian.AppendUint64(buf, 10)
+		buf = binary.LittleEndian.AppendUint64(buf, 4)
+		buf = append(buf, 168, 2)
+		vec, _ := NewVector(10)
+		if _, err := vec.ReadFrom(bytes.NewReader(buf)); err != nil {
+			t.Fatal(err)
+		}
+		chk := map[int]uint8{3: 1, 5: 1, 7: 1, 9: 1}
+		for i := 0; i < 10; i++ {
+			if chk[i] != vec.Get(uint64(i)) {
+				t.Fail()
+			}
+		}
+	})
 }
 
 func BenchmarkVector(b *testing.B) {
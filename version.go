@@ -0,0 +1,163 @@
+package bitvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// DumpVersion is the version of dump format written by WriteTo, WriteCompressed and WriteAdaptive.
+//
+// Versions 1 and 2 are stored as float64 bits, since version 3 the version field is an integer.
+const DumpVersion = 3
+
+// dumpFormat describes dump header of a vector type.
+type dumpFormat struct {
+	kind Kind
+	// Number of 8-byte header fields following signature and version, flags are not included.
+	fields int
+	// Known versions by raw value of version field.
+	versions map[uint64]dumpVersion
+}
+
+// dumpVersion describes header layout of a dump version.
+type dumpVersion struct {
+	num int
+	// Header ends with flags word.
+	flags bool
+}
+
+// dumpFormats is a registry of dump formats by signature. Every type decodes all versions of its registry entry.
+var dumpFormats = map[uint64]dumpFormat{
+	vectorDumpSignature: {
+		kind:   KindVector,
+		fields: 2, // capacity, size
+		versions: map[uint64]dumpVersion{
+			math.Float64bits(vectorDumpVersion1): {num: 1},
+			math.Float64bits(vectorDumpVersion2): {num: 2, flags: true},
+			DumpVersion:                          {num: DumpVersion, flags: true},
+		},
+	},
+	cnVectorDumpSignature: {
+		kind:   KindConcurrentVector,
+		fields: 3, // capacity, size, write attempts limit
+		versions: map[uint64]dumpVersion{
+			math.Float64bits(cnVectorDumpVersion1): {num: 1},
+			math.Float64bits(cnVectorDumpVersion2): {num: 2, flags: true},
+			DumpVersion:                            {num: DumpVersion, flags: true},
+		},
+	},
+	roaringVectorDumpSignature: {
+		kind:   KindRoaringVector,
+		fields: 1, // number of keys
+		versions: map[uint64]dumpVersion{
+			math.Float64bits(roaringVectorDumpVersion1): {num: 1},
+			math.Float64bits(roaringVectorDumpVersion2): {num: 2, flags: true},
+			DumpVersion: {num: DumpVersion, flags: true},
+		},
+	},
+}
+
+// dumpHeader represents parsed dump header.
+type dumpHeader struct {
+	sig    uint64
+	kind   Kind
+	ver    int
+	fields [3]uint64
+	flags  uint64
+	md     Metadata
+	// Header length in bytes including metadata block.
+	len int
+}
+
+// readHeader reads dump header of any known version. If sig isn't zero, the dump must have the given signature.
+func readHeader(r io.Reader, sig uint64) (h dumpHeader, err error) {
+	var buf [48]byte
+	if _, err = io.ReadFull(r, buf[:16]); err != nil {
+		return h, truncated(err)
+	}
+	h.sig = binary.LittleEndian.Uint64(buf[0:8])
+	f, ok := dumpFormats[h.sig]
+	if !ok || (sig != 0 && h.sig != sig) {
+		return h, ErrInvalidSignature
+	}
+	v, ok := f.versions[binary.LittleEndian.Uint64(buf[8:16])]
+	if !ok {
+		return h, ErrVersionMismatch
+	}
+	h.kind, h.ver, h.len = f.kind, v.num, 16+f.fields*8
+	if v.flags {
+		h.len += 8
+	}
+	if _, err = readFull(r, buf[16:h.len]); err != nil {
+		return
+	}
+	for i := 0; i < f.fields; i++ {
+		h.fields[i] = binary.LittleEndian.Uint64(buf[16+i*8:])
+	}
+	if v.flags {
+		h.flags = binary.LittleEndian.Uint64(buf[h.len-8:])
+	}
+	if h.flags&dumpFlagMetadata != 0 {
+		var n int
+		if h.md, n, err = readMetadata(r); err != nil {
+			return
+		}
+		h.len += n
+	}
+	return
+}
+
+// writeHeader writes dump header of the current version followed by metadata block if md isn't empty.
+func writeHeader(w io.Writer, sig, flags uint64, md Metadata, fields ...uint64) error {
+	if len(md) > 0 {
+		flags |= dumpFlagMetadata
+	}
+	buf := make([]byte, 0, 48)
+	buf = binary.LittleEndian.AppendUint64(buf, sig)
+	buf = binary.LittleEndian.AppendUint64(buf, DumpVersion)
+	for _, x := range fields {
+		buf = binary.LittleEndian.AppendUint64(buf, x)
+	}
+	buf = binary.LittleEndian.AppendUint64(buf, flags)
+	if len(md) > 0 {
+		buf = appendMetadata(buf, md)
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// writeDump writes dump of the vector with given flags.
+func writeDump(w io.Writer, vec Interface, flags uint64) (int64, error) {
+	switch x := unwrapJournal(vec).(type) {
+	case *vector:
+		return x.dump(w, flags)
+	case *frozenVector:
+		return x.src.dump(w, flags)
+	case *concurrentVector:
+		return x.dump(w, flags)
+	case *roaringVector:
+		// Roaring vector is sparse by design and isn't block encoded.
+		return x.dump(w, flags&^dumpFlagBlocks)
+	default:
+		return 0, ErrWrongType
+	}
+}
+
+// Migrate reads a dump of any version from r and writes it to w in the current format.
+//
+// Compressed and block encoded dumps stay such after migration. The vector is loaded into memory completely.
+func Migrate(r io.Reader, w io.Writer) (int64, error) {
+	// Read the header first to know encoding of the source dump, then load the dump including the consumed header.
+	var hb bytes.Buffer
+	h, err := readHeader(io.TeeReader(r, &hb), 0)
+	if err != nil {
+		return 0, err
+	}
+	vec, err := Load(&prefixReader{p: hb.Bytes(), r: r})
+	if err != nil {
+		return 0, err
+	}
+	return writeDump(w, vec, dumpFlagChecksum|h.flags&(dumpFlagCompressed|dumpFlagBlocks))
+}
@@ -0,0 +1,121 @@
+package bitvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	kinds := []Kind{KindVector, KindConcurrentVector, KindRoaringVector}
+	dump := func(vec Interface) []byte {
+		var buf bytes.Buffer
+		_, _ = vec.WriteTo(&buf)
+		return buf.Bytes()
+	}
+	// legacy rewrites current dump p of the given kind to version 1 or 2.
+	legacy := func(p []byte, kind Kind, ver int) []byte {
+		var sig uint64
+		for s, f := range dumpFormats {
+			if f.kind == kind {
+				sig = s
+			}
+		}
+		fields := dumpFormats[sig].fields
+		hlen := 16 + fields*8 + 8
+		var q []byte
+		q = binary.LittleEndian.AppendUint64(q, sig)
+		q = binary.LittleEndian.AppendUint64(q, math.Float64bits(float64(ver)))
+		q = append(q, p[16:16+fields*8]...)
+		if ver == 2 {
+			q = binary.LittleEndian.AppendUint64(q, 0)
+		}
+		// Legacy dumps have no checksum trailer.
+		return append(q, p[hlen:len(p)-4]...)
+	}
+	t.Run("legacy", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse("3,5,7-9,100", kind)
+			p := dump(vec)
+			for _, ver := range []int{1, 2} {
+				q := legacy(p, kind, ver)
+				h, err := Inspect(bytes.NewReader(q))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if h.Version != ver || h.Kind != kind {
+					t.Errorf("%s v%d: unexpected header %+v", kind, ver, h)
+				}
+				vec1, err := Load(bytes.NewReader(q))
+				if err != nil {
+					t.Fatalf("%s v%d: %v", kind, ver, err)
+				}
+				if Compare(vec, vec1) != 0 {
+					t.Errorf("%s v%d: got %v, want %v", kind, ver, vec1, vec)
+				}
+			}
+		}
+	})
+	t.Run("migrate", func(t *testing.T) {
+		for _, kind := range kinds {
+			vec, _ := Parse("3,5,7-9,100", kind)
+			p := dump(vec)
+			for _, ver := range []int{1, 2, DumpVersion} {
+				q := p
+				if ver < DumpVersion {
+					q = legacy(p, kind, ver)
+				}
+				var buf bytes.Buffer
+				n, err := Migrate(bytes.NewReader(q), &buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != int64(buf.Len()) || !bytes.Equal(buf.Bytes(), p) {
+					t.Errorf("%s v%d: migrated dump differs", kind, ver)
+				}
+			}
+		}
+	})
+	t.Run("migrate encoded", func(t *testing.T) {
+		vec, _ := Parse("3,5,7-9,100", KindConcurrentVector)
+		var src, dst bytes.Buffer
+		_, _ = WriteAdaptive(&src, vec)
+		if _, err := Migrate(&src, &dst); err != nil {
+			t.Fatal(err)
+		}
+		if h, _ := Inspect(bytes.NewReader(dst.Bytes())); h.Flags&FlagBlocks == 0 {
+			t.Errorf("encoding lost, header %+v", h)
+		}
+	})
+	t.Run("migrate metadata", func(t *testing.T) {
+		md := Metadata{"source": "clickstream", "note": strings.Repeat("x", 100)}
+		for _, kind := range []Kind{KindVector, KindConcurrentVector, KindRoaringVector} {
+			vec, _ := Parse("3,5,7-9,100", kind)
+			_ = SetMetadata(vec, md)
+			var src, dst bytes.Buffer
+			_, _ = WriteCompressed(&src, vec)
+			if _, err := Migrate(&src, &dst); err != nil {
+				t.Fatalf("%s: %v", kind, err)
+			}
+			h, _ := Inspect(bytes.NewReader(dst.Bytes()))
+			if h.Flags&FlagCompressed == 0 || !reflect.DeepEqual(h.Metadata, md) {
+				t.Errorf("%s: unexpected header %+v", kind, h)
+			}
+			vec1, err := Load(&dst)
+			if err != nil || Compare(vec, vec1) != 0 {
+				t.Errorf("%s: got %v, error %v", kind, vec1, err)
+			}
+		}
+	})
+	t.Run("unknown version", func(t *testing.T) {
+		vec, _ := Parse("3,5", KindVector)
+		p := dump(vec)
+		binary.LittleEndian.PutUint64(p[8:], DumpVersion+1)
+		if _, err := Load(bytes.NewReader(p)); err != ErrVersionMismatch {
+			t.Errorf("expected version mismatch error, got %v", err)
+		}
+	})
+}
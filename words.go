@@ -0,0 +1,279 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"slices"
+	"sync/atomic"
+)
+
+// wordsOf returns number of 64-bit words covering the vector and the accessor of i-th word.
+//
+// Words are LSB-first: bit j of word i corresponds to position i*64+j of the vector. Dense vectors are read directly
+// from their buffers, other implementations fall back to Get calls. Roaring vector must be iterated by wordIter.
+func wordsOf(v Interface) (int, func(int) uint64) {
+	switch x := v.(type) {
+	case *vector:
+		buf, msb := x.buf, x.o != 0
+		n := (len(buf) + 7) / 8
+		return n, func(i int) uint64 {
+			off := i * 8
+			var w uint64
+			if off+8 <= len(buf) {
+				w = binary.LittleEndian.Uint64(buf[off:])
+			} else {
+				for j := off; j < len(buf); j++ {
+					w |= uint64(buf[j]) << ((j - off) * 8)
+				}
+			}
+			if msb {
+				w = reverseBytesBits(w)
+			}
+			return w
+		}
+	case *frozenVector:
+		return wordsOf(&x.vector)
+	case *Journal:
+		return wordsOf(x.vec)
+	case *concurrentVector:
+		buf := x.buf
+		n := (len(buf) + 1) / 2
+		return n, func(i int) uint64 {
+			w := uint64(atomic.LoadUint32(&buf[i*2]))
+			if i*2+1 < len(buf) {
+				w |= uint64(atomic.LoadUint32(&buf[i*2+1])) << 32
+			}
+			return w
+		}
+	default:
+		c := v.Capacity()
+		n := int((c + 63) / 64)
+		return n, func(i int) uint64 {
+			var w uint64
+			lo := uint64(i) * 64
+			for j := uint64(0); j < 64 && lo+j < c; j++ {
+				w |= uint64(v.Get(lo+j)) << j
+			}
+			return w
+		}
+	}
+}
+
+// orWordsOf returns the function applying bitwise OR of given word with i-th word of the vector.
+//
+// Dense vectors are written directly without atomics, thus the vector must not be shared during writes.
+func orWordsOf(v Interface) func(uint64, uint64) {
+	switch x := v.(type) {
+	case *vector:
+		buf, msb := x.buf, x.o != 0
+		return func(i uint64, w uint64) {
+			if i >= uint64(len(buf)+7)/8 {
+				return
+			}
+			if msb {
+				w = reverseBytesBits(w)
+			}
+			off := int(i * 8)
+			if off+8 <= len(buf) {
+				binary.LittleEndian.PutUint64(buf[off:], binary.LittleEndian.Uint64(buf[off:])|w)
+				return
+			}
+			for j := off; j < len(buf); j++ {
+				buf[j] |= uint8(w >> ((j - off) * 8))
+			}
+		}
+	case *concurrentVector:
+		buf := x.buf
+		return func(i uint64, w uint64) {
+			if i >= uint64(len(buf)+1)/2 {
+				return
+			}
+			buf[i*2] |= uint32(w)
+			if i*2+1 < uint64(len(buf)) {
+				buf[i*2+1] |= uint32(w >> 32)
+			}
+		}
+	default:
+		return func(i uint64, w uint64) {
+			for w != 0 {
+				v.Set(i*64 + uint64(bits.TrailingZeros64(w)))
+				w &= w - 1
+			}
+		}
+	}
+}
+
+// recount sets size of dense vector to its population count after direct writes.
+func recount(v Interface) {
+	switch x := v.(type) {
+	case *vector:
+		x.s = x.Popcnt()
+	case *concurrentVector:
+		x.s = x.Popcnt()
+	}
+}
+
+// wordIter iterates over non-zero 64-bit words of the vector in ascending order of indices, see wordsOf.
+//
+// Roaring vector is iterated over its containers, so the cost depends on number of set bits rather than on the last
+// set position.
+type wordIter struct {
+	// Number of words covering the vector, including zero ones.
+	n    uint64
+	word func(int) uint64
+	rv   *roaringVector
+	// Index of the next dense word, indices of the next roaring container and its element.
+	i, k, j int
+}
+
+func newWordIter(v Interface) wordIter {
+	v = unwrapJournal(v)
+	if x, ok := v.(*roaringVector); ok {
+		return wordIter{n: (bitLen(x) + 63) / 64, rv: x}
+	}
+	n, word := wordsOf(v)
+	return wordIter{n: uint64(n), word: word}
+}
+
+// next returns index and value of the next non-zero word. False returns if words are exhausted.
+func (it *wordIter) next() (uint64, uint64, bool) {
+	if x := it.rv; x != nil {
+		for it.k < len(x.keys) && it.k < len(x.buf) {
+			b := x.buf[it.k].buf
+			if it.j >= len(b) {
+				it.k, it.j = it.k+1, 0
+				continue
+			}
+			// Container covers 2^32 positions, i.e. 2^26 words.
+			lo := b[it.j] / 64
+			var w uint64
+			for ; it.j < len(b) && b[it.j]/64 == lo; it.j++ {
+				w |= 1 << (b[it.j] % 64)
+			}
+			return uint64(x.keys[it.k])<<26 | uint64(lo), w, true
+		}
+		return 0, 0, false
+	}
+	for it.i < int(it.n) {
+		i := it.i
+		it.i++
+		if w := it.word(i); w != 0 {
+			return uint64(i), w, true
+		}
+	}
+	return 0, 0, false
+}
+
+// mergeWords calls fn for every index of non-zero word of a or b in ascending order until fn returns false. Word of the
+// vector missing the index is zero.
+func mergeWords(a, b Interface, fn func(i, x, y uint64) bool) {
+	ia, ib := newWordIter(a), newWordIter(b)
+	i, x, oka := ia.next()
+	j, y, okb := ib.next()
+	for oka || okb {
+		switch {
+		case !okb || oka && i < j:
+			if !fn(i, x, 0) {
+				return
+			}
+			i, x, oka = ia.next()
+		case !oka || j < i:
+			if !fn(j, 0, y) {
+				return
+			}
+			j, y, okb = ib.next()
+		default:
+			if !fn(i, x, y) {
+				return
+			}
+			i, x, oka = ia.next()
+			j, y, okb = ib.next()
+		}
+	}
+}
+
+// appendZeros appends n zero values to dst and returns the extended slice.
+func appendZeros[T any](dst []T, n int) []T {
+	dst = slices.Grow(dst, n)
+	m := len(dst)
+	dst = dst[:m+n]
+	clear(dst[m:])
+	return dst
+}
+
+// forEach calls fn for every set bit position in ascending order until fn returns false.
+func forEach(v Interface, fn func(uint64) bool) {
+	if x, ok := unwrapJournal(v).(*roaringVector); ok {
+		for i := 0; i < len(x.keys) && i < len(x.buf); i++ {
+			hi := uint64(x.keys[i]) << 32
+			for _, lo := range x.buf[i].buf {
+				if !fn(hi | uint64(lo)) {
+					return
+				}
+			}
+		}
+		return
+	}
+	it := newWordIter(v)
+	for i, w, ok := it.next(); ok; i, w, ok = it.next() {
+		for ; w != 0; w &= w - 1 {
+			if !fn(i*64 + uint64(bits.TrailingZeros64(w))) {
+				return
+			}
+		}
+	}
+}
+
+// sizeOf returns logical size of the vector, i.e. size given on creation.
+func sizeOf(v Interface) uint64 {
+	switch x := v.(type) {
+	case *vector:
+		return x.c
+	case *frozenVector:
+		return x.c
+	case *concurrentVector:
+		return x.c
+	case *Journal:
+		return sizeOf(x.vec)
+	default:
+		return bitLen(v)
+	}
+}
+
+// bitLen returns number of bits the vector spans. For roaring vector it's the last set position plus one.
+func bitLen(v Interface) uint64 {
+	if x, ok := unwrapJournal(v).(*roaringVector); ok {
+		n := min(len(x.keys), len(x.buf))
+		for i := n - 1; i >= 0; i-- {
+			if b := x.buf[i].buf; len(b) > 0 {
+				return (uint64(x.keys[i])<<32 | uint64(b[len(b)-1])) + 1
+			}
+		}
+		return 0
+	}
+	return v.Capacity()
+}
+
+// bytesOf returns underlying memory of the vector or nil if it has no dense representation.
+func bytesOf(v Interface) []byte {
+	if d, ok := v.(Dense); ok {
+		return d.Bytes()
+	}
+	return nil
+}
+
+// setRange writes bits in range [lo, hi) using SetRange if the vector implements RangeSetter or bit by bit otherwise.
+func setRange(v Interface, lo, hi uint64) bool {
+	if rs, ok := v.(RangeSetter); ok {
+		return rs.SetRange(lo, hi)
+	}
+	if lo > hi {
+		return false
+	}
+	for i := lo; i < hi; i++ {
+		if !v.Set(i) {
+			return false
+		}
+	}
+	return true
+}